@@ -0,0 +1,385 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/publish"
+)
+
+// queryInt reads a positive integer query parameter, returning def when absent.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, badRequest("invalid %s '%s': must be a positive integer", name, value)
+	}
+	return n, nil
+}
+
+// queryBool reads an optional boolean query parameter.
+func queryBool(r *http.Request, name string) (sql.NullBool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return sql.NullBool{}, badRequest("invalid %s '%s': must be true or false", name, value)
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// queryDate reads an optional date query parameter.
+func queryDate(r *http.Request, name string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := parseDate(value)
+	if err != nil {
+		return sql.NullTime{}, badRequest("%s: %v", name, err)
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+func (a *apiServer) handleMe(w http.ResponseWriter, r *http.Request, user database.User) error {
+	writeJSON(w, http.StatusOK, output.NewRecord(user))
+	return nil
+}
+
+func (a *apiServer) handleListUsers(w http.ResponseWriter, r *http.Request, user database.User) error {
+	users, err := a.state.DB.GetUsers(r.Context())
+	if err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(users))
+	return nil
+}
+
+func (a *apiServer) handleListFeeds(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feeds, err := a.state.DB.GetFeedsWithUserName(r.Context())
+	if err != nil {
+		return fmt.Errorf("failed to fetch feeds: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(feeds))
+	return nil
+}
+
+func (a *apiServer) handleAddFeed(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+	if body.Name == "" || body.URL == "" {
+		return badRequest("name and url are required")
+	}
+
+	newFeed, _, err := addFeed(r.Context(), a.state, user.ID, body.Name, body.URL)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, output.NewRecord(newFeed))
+	return nil
+}
+
+func (a *apiServer) handleListFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	follows, err := a.state.DB.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed follows: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(follows))
+	return nil
+}
+
+type followRequest struct {
+	URL      string `json:"url"`
+	Category string `json:"category"`
+}
+
+func (a *apiServer) handleFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body followRequest
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+	if body.URL == "" {
+		return badRequest("url is required")
+	}
+
+	follow, err := followFeed(r.Context(), a.state, user.ID, body.URL, categoryParam(body.Category))
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, output.NewRecord(follow))
+	return nil
+}
+
+func (a *apiServer) handleMoveFollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body followRequest
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+	if body.URL == "" {
+		return badRequest("url is required")
+	}
+
+	if _, err := moveFeed(r.Context(), a.state, user.ID, body.URL, categoryParam(body.Category)); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (a *apiServer) handleUnfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := r.URL.Query().Get("url")
+	if feedURL == "" {
+		return badRequest("url query parameter is required")
+	}
+
+	if _, err := unfollowFeed(r.Context(), a.state, user.ID, feedURL); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (a *apiServer) handleListPosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	query := r.URL.Query()
+	limit, err := queryInt(r, "limit", defaultBrowseLimit)
+	if err != nil {
+		return err
+	}
+	all, err := queryBool(r, "all")
+	if err != nil {
+		return err
+	}
+	lang, err := languageParam(query.Get("lang"))
+	if err != nil {
+		return badRequest("%v", err)
+	}
+
+	posts, err := a.state.DB.GetPostsForUser(r.Context(), database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: all.Bool,
+		Tag:         sql.NullString{String: strings.ToLower(query.Get("tag")), Valid: query.Get("tag") != ""},
+		Category:    categoryParam(query.Get("category")),
+		Lang:        lang,
+		Lim:         int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(posts))
+	return nil
+}
+
+func (a *apiServer) handleSearchPosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	query := r.URL.Query()
+	if query.Get("q") == "" {
+		return badRequest("q query parameter is required")
+	}
+	limit, err := queryInt(r, "limit", defaultSearchLimit)
+	if err != nil {
+		return err
+	}
+	isRead, err := queryBool(r, "read")
+	if err != nil {
+		return err
+	}
+	since, err := queryDate(r, "since")
+	if err != nil {
+		return err
+	}
+	until, err := queryDate(r, "until")
+	if err != nil {
+		return err
+	}
+	lang, err := languageParam(query.Get("lang"))
+	if err != nil {
+		return badRequest("%v", err)
+	}
+
+	params := database.SearchPostsParams{
+		Lang:   lang,
+		Query:  query.Get("q"),
+		UserID: user.ID,
+		Since:  since,
+		Until:  until,
+		IsRead: isRead,
+		Tag:    sql.NullString{String: strings.ToLower(query.Get("tag")), Valid: query.Get("tag") != ""},
+		Lim:    int32(limit),
+	}
+	if feedURL := query.Get("feed"); feedURL != "" {
+		feed, err := getFeedByURL(r.Context(), a.state.DB, a.state.URLs, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	results, err := a.state.DB.SearchPosts(r.Context(), params)
+	if err != nil {
+		return fmt.Errorf("failed to search posts: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(results))
+	return nil
+}
+
+// writeAffected reports how many posts a read/star change touched.
+func writeAffected(w http.ResponseWriter, affected int64) {
+	writeJSON(w, http.StatusOK, map[string]int64{"affected": affected})
+}
+
+func (a *apiServer) handleMarkRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	ctx := r.Context()
+	now := time.Now().UTC()
+	n, err := forEachPostRange(ctx, a.state.DB, []string{r.PathValue("ref")}, func(pr postRange) (int64, error) {
+		return a.state.DB.MarkPostsRead(ctx, database.MarkPostsReadParams{
+			UserID:  user.ID,
+			ReadAt:  now,
+			FromSeq: pr.From,
+			ToSeq:   pr.To,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writeAffected(w, n)
+	return nil
+}
+
+func (a *apiServer) handleMarkUnread(w http.ResponseWriter, r *http.Request, user database.User) error {
+	ctx := r.Context()
+	n, err := forEachPostRange(ctx, a.state.DB, []string{r.PathValue("ref")}, func(pr postRange) (int64, error) {
+		return a.state.DB.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{
+			UserID:  user.ID,
+			FromSeq: pr.From,
+			ToSeq:   pr.To,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writeAffected(w, n)
+	return nil
+}
+
+func (a *apiServer) handleStar(w http.ResponseWriter, r *http.Request, user database.User) error {
+	ctx := r.Context()
+	now := time.Now().UTC()
+	n, err := forEachPostRange(ctx, a.state.DB, []string{r.PathValue("ref")}, func(pr postRange) (int64, error) {
+		return a.state.DB.StarPosts(ctx, database.StarPostsParams{
+			UserID:    user.ID,
+			StarredAt: now,
+			FromSeq:   pr.From,
+			ToSeq:     pr.To,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writeAffected(w, n)
+	return nil
+}
+
+func (a *apiServer) handleUnstar(w http.ResponseWriter, r *http.Request, user database.User) error {
+	ctx := r.Context()
+	n, err := forEachPostRange(ctx, a.state.DB, []string{r.PathValue("ref")}, func(pr postRange) (int64, error) {
+		return a.state.DB.UnstarPosts(ctx, database.UnstarPostsParams{
+			UserID:  user.ID,
+			FromSeq: pr.From,
+			ToSeq:   pr.To,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writeAffected(w, n)
+	return nil
+}
+
+func (a *apiServer) handleListStars(w http.ResponseWriter, r *http.Request, user database.User) error {
+	posts, err := a.state.DB.GetStarredPostsForUser(r.Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch starred posts: %w", err)
+	}
+	writeJSON(w, http.StatusOK, output.NewRecords(posts))
+	return nil
+}
+
+// handlePublish renders the caller's timeline as an Atom (default) or RSS feed,
+// filtered the same way as gator publish.
+func (a *apiServer) handlePublish(w http.ResponseWriter, r *http.Request, user database.User) error {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = publish.FormatAtom
+	}
+	if format != publish.FormatAtom && format != publish.FormatRSS {
+		return badRequest("unknown format '%s': expected atom or rss", format)
+	}
+	limit, err := queryInt(r, "limit", defaultPublishLimit)
+	if err != nil {
+		return err
+	}
+	unread, err := queryBool(r, "unread")
+	if err != nil {
+		return err
+	}
+	since, err := queryDate(r, "since")
+	if err != nil {
+		return err
+	}
+	if _, err := languageParam(query.Get("lang")); err != nil {
+		return badRequest("%v", err)
+	}
+
+	doc, err := buildPublishedFeed(r.Context(), a.state, user, publishFilter{
+		Tag:      query.Get("tag"),
+		Category: query.Get("category"),
+		FeedURL:  query.Get("feed"),
+		Lang:     query.Get("lang"),
+		Since:    since,
+		Unread:   unread.Bool,
+		Limit:    limit,
+		Link:     selfLink(r),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", publish.ContentType(format))
+	return publish.Write(w, format, doc)
+}
+
+// selfLink reconstructs the URL a request was made to, minus any key query
+// parameter so published documents never contain credentials.
+func selfLink(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	query := r.URL.Query()
+	query.Del("key")
+	u := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+// argSpec describes a positional argument. A variadic argument takes all the
+// remaining arguments and must come last. Complete, if set, supplies its
+// values for shell completion.
+type argSpec struct {
+	Name     string
+	Optional bool
+	Variadic bool
+	Complete completer
+}
+
+// commandSpec declares a command for the registry: its help text, flags and
+// positional arguments. Commands with subcommands dispatch on their first
+// argument and have no handler of their own. RawArgs commands receive their
+// arguments unparsed. Records commands print their result, or the records they
+// list, in the format given by the global --output flag. The rest, such as
+// tui and serve, have no result to print and refuse any format but text.
+type commandSpec struct {
+	Name            string
+	Summary         string
+	Description     string
+	Args            []argSpec
+	Flags           func(fs *flag.FlagSet)
+	FlagCompletions map[string]completer
+	Examples        []string
+	Subcommands     []*commandSpec
+	Hidden          bool
+	RawArgs         bool
+	Records         bool
+	Handler         commandHandlerFunc
+}
+
+// usage returns the one-line synopsis, e.g. "gator follow <url> [flags]".
+func (c *commandSpec) usage(path string) string {
+	parts := []string{"gator", path}
+	if len(c.Subcommands) > 0 {
+		parts = append(parts, "<subcommand>")
+	}
+	for _, a := range c.Args {
+		name := "<" + a.Name + ">"
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			name = "[" + name + "]"
+		}
+		parts = append(parts, name)
+	}
+	if c.Flags != nil {
+		parts = append(parts, "[flags]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *commandSpec) subcommand(name string) *commandSpec {
+	return findSpec(c.Subcommands, name)
+}
+
+// argAt returns the spec of the i-th positional argument.
+func (c *commandSpec) argAt(i int) (argSpec, bool) {
+	if i < len(c.Args) {
+		return c.Args[i], true
+	}
+	if n := len(c.Args); n > 0 && c.Args[n-1].Variadic {
+		return c.Args[n-1], true
+	}
+	return argSpec{}, false
+}
+
+// flagSet builds the command's flags. Parse errors are reported by the
+// registry rather than printed by the flag package.
+func (c *commandSpec) flagSet(path string) *flag.FlagSet {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.Flags != nil {
+		c.Flags(fs)
+	}
+	return fs
+}
+
+// checkArgs validates the number of positional arguments against the spec.
+func (c *commandSpec) checkArgs(args []string) error {
+	required, variadic := 0, false
+	for _, a := range c.Args {
+		if !a.Optional {
+			required++
+		}
+		variadic = variadic || a.Variadic
+	}
+
+	switch {
+	case len(c.Args) == 0 && len(args) > 0:
+		return fmt.Errorf("takes no arguments, got %d", len(args))
+	case len(args) < required:
+		var missing []string
+		for _, a := range c.Args[len(args):] {
+			if !a.Optional {
+				missing = append(missing, "<"+a.Name+">")
+			}
+		}
+		return fmt.Errorf("missing required argument %s", strings.Join(missing, " "))
+	case !variadic && len(args) > len(c.Args):
+		return fmt.Errorf("takes at most %d arguments, got %d", len(c.Args), len(args))
+	}
+	return nil
+}
+
+// usageError is a mistake in how a command was invoked. It is reported with
+// a pointer to the command's help.
+type usageError struct {
+	Path string
+	Err  error
+}
+
+func (e *usageError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%v\nRun 'gator help' for a list of commands.", e.Err)
+	}
+	return fmt.Sprintf("%v\nRun 'gator help %s' for usage.", e.Err, e.Path)
+}
+
+func (e *usageError) Unwrap() error {
+	return e.Err
+}
+
+// command is a parsed invocation: positional arguments plus the flags
+// declared by its spec.
+type command struct {
+	Name  string
+	Args  []string
+	Flags *flag.FlagSet
+}
+
+// String, Bool and Int return the values of flags declared by the command's
+// spec. Asking for an undeclared flag is a programming error.
+func (c command) String(name string) string {
+	return c.flag(name).(string)
+}
+
+func (c command) Bool(name string) bool {
+	return c.flag(name).(bool)
+}
+
+func (c command) Int(name string) int {
+	return c.flag(name).(int)
+}
+
+func (c command) flag(name string) any {
+	var f *flag.Flag
+	if c.Flags != nil {
+		f = c.Flags.Lookup(name)
+	}
+	if f == nil {
+		panic(fmt.Sprintf("command %s has no flag --%s", c.Name, name))
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+type commandHandlerFunc func(s *state, cmd command) error
+
+type commands struct {
+	specs []*commandSpec
+}
+
+func (c *commands) register(spec *commandSpec) {
+	c.specs = append(c.specs, spec)
+}
+
+func (c *commands) lookup(name string) *commandSpec {
+	return findSpec(c.specs, name)
+}
+
+// run resolves name (and any subcommand) against the registry, parses flags
+// and positional arguments according to the spec and calls the handler.
+// --help on any command prints its help instead.
+func (c *commands) run(s *state, name string, args []string) error {
+	spec := c.lookup(name)
+	if spec == nil {
+		return unknownCommand(name, "gator", c.specs)
+	}
+
+	path := name
+	for len(spec.Subcommands) > 0 {
+		if len(args) == 0 || isHelpFlag(args[0]) {
+			return printCommandHelp(os.Stdout, path, spec)
+		}
+		sub := spec.subcommand(args[0])
+		if sub == nil {
+			return unknownCommand(args[0], "gator "+path, spec.Subcommands)
+		}
+		path += " " + sub.Name
+		spec, args = sub, args[1:]
+	}
+	if spec.RawArgs {
+		return spec.Handler(s, command{Name: path, Args: args})
+	}
+
+	fs := spec.flagSet(path)
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return printCommandHelp(os.Stdout, path, spec)
+	}
+	if err != nil {
+		return &usageError{Path: path, Err: err}
+	}
+	if err := spec.checkArgs(positional); err != nil {
+		return &usageError{Path: path, Err: fmt.Errorf("%s %w", path, err)}
+	}
+	if s.Output != output.Text && !spec.Records {
+		return &usageError{Path: path, Err: fmt.Errorf("%s only prints text; --output %s is not supported", path, s.Output)}
+	}
+
+	return spec.Handler(s, command{Name: path, Args: positional, Flags: fs})
+}
+
+func findSpec(specs []*commandSpec, name string) *commandSpec {
+	for _, spec := range specs {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// unknownCommand reports a command or subcommand that does not exist,
+// suggesting the closest known names.
+func unknownCommand(name, parent string, specs []*commandSpec) error {
+	msg := fmt.Sprintf("unknown command '%s' for '%s'", name, parent)
+	if suggestions := suggest(name, specs); len(suggestions) > 0 {
+		msg += fmt.Sprintf("\n\nDid you mean this?\n\t%s", strings.Join(suggestions, "\n\t"))
+	}
+	return &usageError{Path: strings.TrimSpace(strings.TrimPrefix(parent, "gator")), Err: errors.New(msg)}
+}
+
+// suggest returns visible command names within a small edit distance of
+// name, or that name is a prefix of, closest first.
+func suggest(name string, specs []*commandSpec) []string {
+	type candidate struct {
+		name string
+		dist int
+	}
+	var candidates []candidate
+	for _, spec := range specs {
+		if spec.Hidden {
+			continue
+		}
+		d := editDistance(strings.ToLower(name), spec.Name)
+		if d <= 2 || strings.HasPrefix(spec.Name, strings.ToLower(name)) {
+			candidates = append(candidates, candidate{spec.Name, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// printHelp lists every visible command.
+func printHelp(w io.Writer, specs []*commandSpec) error {
+	fmt.Fprintln(w, "Gator is a command-line RSS feed aggregator.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  gator <command> [arguments] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, spec := range specs {
+		if !spec.Hidden {
+			fmt.Fprintf(tw, "  %s\t%s\n", spec.Name, spec.Summary)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	printGlobalFlags(w)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gator help <command>' or 'gator <command> --help' for details on a command.")
+	return nil
+}
+
+func printGlobalFlags(w io.Writer) {
+	fmt.Fprintln(w, "Global flags:")
+	fmt.Fprintln(w, "  -o, --output format   output format: text, json, jsonl, csv or table (default \"text\")")
+}
+
+// printCommandHelp prints the usage, description, subcommands, flags and
+// examples of one command.
+func printCommandHelp(w io.Writer, path string, spec *commandSpec) error {
+	fmt.Fprintf(w, "Usage: %s\n\n", spec.usage(path))
+	if spec.Description != "" {
+		fmt.Fprintln(w, spec.Description)
+	} else {
+		fmt.Fprintln(w, spec.Summary)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if len(spec.Subcommands) > 0 {
+		fmt.Fprintln(tw, "\nSubcommands:")
+		for _, sub := range spec.Subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", strings.TrimPrefix(sub.usage(path+" "+sub.Name), "gator "+path+" "), sub.Summary)
+		}
+	}
+
+	fs := spec.flagSet(path)
+	hasFlags := false
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hasFlags {
+			fmt.Fprintln(tw, "\nFlags:")
+			hasFlags = true
+		}
+		typeName, usage := flag.UnquoteUsage(f)
+		line := "  --" + f.Name
+		if typeName != "" {
+			line += " " + typeName
+		}
+		line += "\t" + usage
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			if _, ok := f.Value.(flag.Getter).Get().(string); ok {
+				line += fmt.Sprintf(" (default %q)", f.DefValue)
+			} else {
+				line += fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+		}
+		fmt.Fprintln(tw, line)
+	})
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if spec.Records {
+		fmt.Fprintln(w)
+		printGlobalFlags(w)
+	}
+
+	if len(spec.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, ex := range spec.Examples {
+			fmt.Fprintf(w, "  %s\n", ex)
+		}
+	}
+	return nil
+}
+
+// helpCommand builds the help command, which needs the registry it lives in.
+func helpCommand(registry *commands) *commandSpec {
+	return &commandSpec{
+		Name:    "help",
+		Summary: "Shows help for gator or one of its commands.",
+		Args:    []argSpec{{Name: "command", Optional: true, Variadic: true}},
+		Examples: []string{
+			"gator help",
+			"gator help apikey create",
+		},
+		Handler: func(s *state, cmd command) error {
+			if len(cmd.Args) == 0 {
+				return printHelp(os.Stdout, registry.specs)
+			}
+			spec := registry.lookup(cmd.Args[0])
+			if spec == nil {
+				return unknownCommand(cmd.Args[0], "gator", registry.specs)
+			}
+			path := spec.Name
+			for _, name := range cmd.Args[1:] {
+				sub := spec.subcommand(name)
+				if sub == nil {
+					return unknownCommand(name, "gator "+path, spec.Subcommands)
+				}
+				spec, path = sub, path+" "+name
+			}
+			return printCommandHelp(os.Stdout, path, spec)
+		},
+	}
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Numpkens/gatorcli/internal/auth"
+	"github.com/Numpkens/gatorcli/internal/publish"
+	"github.com/Numpkens/gatorcli/internal/rules"
+)
+
+// postRefsArg is the positional argument of commands that act on posts.
+var postRefsArg = argSpec{Name: "post", Variadic: true}
+
+// feedOrPostArg is the target of tag and untag.
+var feedOrPostArg = argSpec{Name: "post|feed-url", Complete: completeFollowedFeeds}
+
+// registerCommands declares every gator command. Help lists them in this
+// order.
+func registerCommands(c *commands) {
+	c.register(&commandSpec{
+		Name:     "register",
+		Summary:  "Registers a new user and sets them as the current user.",
+		Args:     []argSpec{{Name: "username"}},
+		Examples: []string{"gator register alice"},
+		Records:  true,
+		Handler:  handlerRegister,
+	})
+	c.register(&commandSpec{
+		Name:     "login",
+		Summary:  "Sets an existing user as the current user.",
+		Args:     []argSpec{{Name: "username", Complete: completeUsers}},
+		Examples: []string{"gator login alice"},
+		Records:  true,
+		Handler:  handlerLogin,
+	})
+	c.register(&commandSpec{
+		Name:        "reset",
+		Summary:     "Deletes all users, feeds and posts.",
+		Description: "Deletes all users and everything that belongs to them: feeds, follows, posts, read state, stars, tags and API keys. There is no undo.",
+		Records:     true,
+		Handler:     handlerReset,
+	})
+	c.register(&commandSpec{
+		Name:     "addfeed",
+		Summary:  "Adds a new feed and automatically follows it.",
+		Args:     []argSpec{{Name: "name"}, {Name: "url"}},
+		Examples: []string{`gator addfeed "Hacker News" "https://hnrss.org/newest"`},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerAddFeed),
+	})
+	c.register(&commandSpec{
+		Name:     "feeds",
+		Summary:  "Lists all feeds known to the system.",
+		Examples: []string{"gator feeds -o json"},
+		Records:  true,
+		Handler:  handlerListFeeds,
+	})
+	c.register(&commandSpec{
+		Name:    "follow",
+		Summary: "Starts following an existing feed URL, optionally in a category.",
+		Args:    []argSpec{{Name: "url", Complete: completeUnfollowedFeeds}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("category", "", "file the feed under this category")
+		},
+		FlagCompletions: map[string]completer{"category": completeCategories},
+		Examples:        []string{`gator follow "https://techcrunch.com/feed/" --category Tech`},
+		Records:         true,
+		Handler:         middlewareLoggedIn(handlerFollow),
+	})
+	c.register(&commandSpec{
+		Name:     "unfollow",
+		Summary:  "Stops following a feed URL.",
+		Args:     []argSpec{{Name: "url", Complete: completeFollowedFeeds}},
+		Examples: []string{`gator unfollow "https://hnrss.org/newest"`},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerUnfollow),
+	})
+	c.register(&commandSpec{
+		Name:     "following",
+		Summary:  "Lists followed feeds grouped by category, with unread counts.",
+		Examples: []string{"gator following"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerFollowing),
+	})
+	c.register(&commandSpec{
+		Name:    "move",
+		Summary: "Moves a followed feed into a category, or clears it when none is given.",
+		Args: []argSpec{
+			{Name: "url", Complete: completeFollowedFeeds},
+			{Name: "category", Optional: true, Complete: completeCategories},
+		},
+		Examples: []string{`gator move "https://techcrunch.com/feed/" News`},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerMove),
+	})
+	c.register(&commandSpec{
+		Name:        "agg",
+		Summary:     "Runs the background feed fetching loop.",
+		Description: "Fetches the least recently fetched feed, saves any new posts, then waits for the given interval (e.g. 30s, 1m) before repeating. Stop it with Ctrl+C.",
+		Args:        []argSpec{{Name: "interval"}},
+		Examples:    []string{"gator agg 30s", "gator agg 1m -o jsonl"},
+		Records:     true,
+		Handler:     handlerAgg,
+	})
+	c.register(&commandSpec{
+		Name:        "dedupe",
+		Summary:     "Canonicalizes stored URLs and merges duplicate feeds and posts.",
+		Description: "Rewrites every feed URL and post link to its canonical form (see tracking_params in ~/.gatorcli.json) and merges feeds and posts that turn out to be the same, keeping the oldest. Follows, tags, read state and stars move to the kept feed or post. Run it once after upgrading; new feeds and posts are canonicalized as they are added.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("dry-run", false, "report what would change without changing anything")
+		},
+		Examples: []string{"gator dedupe --dry-run", "gator dedupe"},
+		Records:  true,
+		Handler:  handlerDedupe,
+	})
+	c.register(&commandSpec{
+		Name:    "browse",
+		Summary: "Lists unread posts from followed feeds.",
+		Args:    []argSpec{{Name: "limit", Optional: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("all", false, "include posts that have already been read")
+			fs.String("tag", "", "only show posts with this tag, directly or via their feed")
+			fs.String("category", "", "only show posts from feeds in this category")
+			fs.String("lang", "", "only show posts in this language (e.g. en, de, ja)")
+		},
+		FlagCompletions: map[string]completer{"tag": completeTags, "category": completeCategories},
+		Examples:        []string{"gator browse 20 --all --tag news"},
+		Records:         true,
+		Handler:         middlewareLoggedIn(handlerBrowse),
+	})
+	c.register(&commandSpec{
+		Name:        "read",
+		Summary:     "Shows a post and marks it read, or marks posts read by number, range or ID.",
+		Description: "Shows a single post, rendered for the terminal, and marks it as read. With a range like 40-45 or several posts, they are marked as read without being shown. Posts are given by the number shown in browse, a range, or a post ID.",
+		Args:        []argSpec{postRefsArg},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("mark-only", false, "only mark the post as read, without showing it")
+		},
+		Examples: []string{"gator read 42", "gator read 40-45"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerRead),
+	})
+	c.register(&commandSpec{
+		Name:     "unread",
+		Summary:  "Marks posts as unread again.",
+		Args:     []argSpec{postRefsArg},
+		Examples: []string{"gator unread 42"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerUnread),
+	})
+	c.register(&commandSpec{
+		Name:    "markallread",
+		Summary: "Marks all posts as read, optionally for one feed or before a date.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("feed", "", "only mark posts from the feed with this URL")
+			fs.String("before", "", "only mark posts published before this date")
+		},
+		FlagCompletions: map[string]completer{"feed": completeFollowedFeeds},
+		Examples:        []string{`gator markallread --feed "https://hnrss.org/newest" --before 2025-01-01`},
+		Records:         true,
+		Handler:         middlewareLoggedIn(handlerMarkAllRead),
+	})
+	c.register(&commandSpec{
+		Name:     "star",
+		Summary:  "Stars posts to save them for later.",
+		Args:     []argSpec{postRefsArg},
+		Examples: []string{"gator star 42"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerStar),
+	})
+	c.register(&commandSpec{
+		Name:     "unstar",
+		Summary:  "Removes the star from posts.",
+		Args:     []argSpec{postRefsArg},
+		Examples: []string{"gator unstar 42"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerUnstar),
+	})
+	c.register(&commandSpec{
+		Name:     "starred",
+		Summary:  "Lists starred posts, including ones from feeds you no longer follow.",
+		Examples: []string{"gator starred"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerStarred),
+	})
+	c.register(&commandSpec{
+		Name:        "tag",
+		Summary:     "Tags posts or a feed.",
+		Description: "Tags posts (by number or range) or a feed (by URL). Feed tags apply to all of the feed's posts.",
+		Args:        []argSpec{feedOrPostArg, {Name: "tag", Variadic: true, Complete: completeTags}},
+		Examples:    []string{`gator tag "https://hnrss.org/newest" news`, "gator tag 40-45 go databases"},
+		Records:     true,
+		Handler:     middlewareLoggedIn(handlerTag),
+	})
+	c.register(&commandSpec{
+		Name:     "untag",
+		Summary:  "Removes tags from posts or a feed.",
+		Args:     []argSpec{feedOrPostArg, {Name: "tag", Variadic: true, Complete: completeTags}},
+		Examples: []string{"gator untag 42 news"},
+		Records:  true,
+		Handler:  middlewareLoggedIn(handlerUntag),
+	})
+	c.register(&commandSpec{
+		Name:        "rule",
+		Summary:     "Adds, lists, removes, tests or applies rules that act on new posts.",
+		Description: "Rules act on each new post in the feeds you follow as the aggregator saves it. A rule fires when every condition given matches, and then marks the post read, stars, tags or hides it, or notifies you.",
+		Subcommands: []*commandSpec{
+			{
+				Name:        "add",
+				Summary:     "Adds a rule.",
+				Description: "Adds a rule with at least one condition. --match and --keywords look in the title and content, or just the part named by --in; patterns and keywords ignore case, and keywords match whole words. Hidden posts are also marked read.",
+				Args:        []argSpec{{Name: "name"}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("match", "", "regular expression the post must match")
+					fs.String("keywords", "", "comma-separated words, any of which the post must mention")
+					fs.String("in", rules.ScopeAny, "where --match and --keywords look: any, title or content")
+					fs.String("feed", "", "only posts from the feed with this URL")
+					fs.String("author", "", "only posts whose author contains this")
+					fs.String("category", "", "only posts from feeds you follow in this category")
+					fs.String("lang", "", "only posts in this language (e.g. en, de, ja)")
+					fs.String("action", "", "what to do: read, star, tag, hide or notify")
+					fs.String("tag", "", "tag to add, for the tag action")
+				},
+				FlagCompletions: map[string]completer{
+					"in":       completeWords(rules.Scopes...),
+					"feed":     completeFollowedFeeds,
+					"category": completeCategories,
+					"action":   completeWords(rules.Actions...),
+					"tag":      completeTags,
+				},
+				Examples: []string{
+					`gator rule add golang --keywords "go, golang" --in title --action tag --tag go`,
+					`gator rule add no-sports --category news --keywords "football, cricket" --action hide`,
+					`gator rule add outages --match "outage|incident" --action notify`,
+				},
+				Records: true,
+				Handler: middlewareLoggedIn(handlerRuleAdd),
+			},
+			{
+				Name:    "list",
+				Summary: "Lists your rules.",
+				Records: true,
+				Handler: middlewareLoggedIn(handlerRuleList),
+			},
+			{
+				Name:    "remove",
+				Summary: "Removes a rule by name.",
+				Args:    []argSpec{{Name: "name", Complete: completeRules}},
+				Records: true,
+				Handler: middlewareLoggedIn(handlerRuleRemove),
+			},
+			{
+				Name:        "test",
+				Summary:     "Shows which posts a rule would act on, without acting.",
+				Description: "Checks a rule against posts already saved and lists the ones it matches, with the action it would take. Nothing is changed. Given posts by number, range or ID, it checks those and marks matches with *; otherwise it checks every post from feeds you follow published since --since.",
+				Args:        []argSpec{{Name: "name", Complete: completeRules}, {Name: "post", Optional: true, Variadic: true}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("since", defaultRuleSince, "check posts published since this age (12h, 30d, 2w) or date")
+				},
+				Examples: []string{"gator rule test golang --since 2w", "gator rule test golang 40-60"},
+				Records:  true,
+				Handler:  middlewareLoggedIn(handlerRuleTest),
+			},
+			{
+				Name:        "apply",
+				Summary:     "Runs a rule, or all your rules, over posts already saved.",
+				Description: "Takes a rule's action on every post from feeds you follow published since --since that it matches, as if the posts had just arrived. Without a name, all your rules are applied. Use rule test first to see what would change.",
+				Args:        []argSpec{{Name: "name", Optional: true, Complete: completeRules}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("since", defaultRuleSince, "apply to posts published since this age (12h, 30d, 2w) or date")
+				},
+				Examples: []string{"gator rule apply golang --since 30d", "gator rule apply --since 7d"},
+				Records:  true,
+				Handler:  middlewareLoggedIn(handlerRuleApply),
+			},
+		},
+	})
+	c.register(&commandSpec{
+		Name:        "webhook",
+		Summary:     "Adds, lists or removes webhooks for new posts, or shows their delivery log.",
+		Description: "The aggregator POSTs a signed JSON payload to each of your webhooks for every new post it should receive. Failed deliveries are retried with exponential backoff for several hours.",
+		Subcommands: []*commandSpec{
+			{
+				Name:        "add",
+				Summary:     "Adds a webhook.",
+				Description: "Adds a webhook that receives every new post in the feeds you follow, or only those from --feed, or only those --rule matches. Each payload is signed with HMAC-SHA256 of the body in the X-Gator-Signature-256 header; a secret is generated and shown unless --secret is given.",
+				Args:        []argSpec{{Name: "url"}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("feed", "", "only posts from the feed with this URL")
+					fs.String("rule", "", "only posts this rule matches")
+					fs.String("secret", "", "signing secret to use instead of a generated one")
+				},
+				FlagCompletions: map[string]completer{
+					"feed": completeFollowedFeeds,
+					"rule": completeRules,
+				},
+				Examples: []string{
+					"gator webhook add https://hooks.example.com/gator",
+					"gator webhook add https://hooks.example.com/outages --rule outages",
+				},
+				Records: true,
+				Handler: middlewareLoggedIn(handlerWebhookAdd),
+			},
+			{
+				Name:    "list",
+				Summary: "Lists your webhooks with delivery counts.",
+				Records: true,
+				Handler: middlewareLoggedIn(handlerWebhookList),
+			},
+			{
+				Name:    "remove",
+				Summary: "Removes a webhook by ID or URL.",
+				Args:    []argSpec{{Name: "id|url", Complete: completeWebhooks}},
+				Records: true,
+				Handler: middlewareLoggedIn(handlerWebhookRemove),
+			},
+			{
+				Name:    "log",
+				Summary: "Shows recent delivery attempts, newest first.",
+				Args:    []argSpec{{Name: "id", Optional: true, Complete: completeWebhooks}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.Int("limit", defaultWebhookLogLimit, "maximum number of attempts")
+				},
+				Examples: []string{"gator webhook log --limit 50"},
+				Records:  true,
+				Handler:  middlewareLoggedIn(handlerWebhookLog),
+			},
+		},
+	})
+	c.register(&commandSpec{
+		Name:    "opml",
+		Summary: "Imports or exports followed feeds and their categories as OPML.",
+		Subcommands: []*commandSpec{
+			{
+				Name:        "import",
+				Summary:     "Follows every feed in an OPML file, filed under its folder.",
+				Description: "Follows every feed in an OPML file, creating feeds that do not exist yet. Each follow is filed under the folder it was found in; feeds already followed are moved to match the file.",
+				Args:        []argSpec{{Name: "file", Complete: completeFiles}},
+				Examples:    []string{"gator opml import feeds.opml"},
+				Records:     true,
+				Handler:     middlewareLoggedIn(handlerOPMLImport),
+			},
+			{
+				Name:     "export",
+				Summary:  "Writes followed feeds as OPML to a file or stdout.",
+				Args:     []argSpec{{Name: "file", Optional: true, Complete: completeFiles}},
+				Examples: []string{"gator opml export feeds.opml"},
+				Records:  true,
+				Handler:  middlewareLoggedIn(handlerOPMLExport),
+			},
+		},
+	})
+	c.register(&commandSpec{
+		Name:        "search",
+		Summary:     "Full-text searches posts with ranked, highlighted results.",
+		Description: `Full-text searches posts with ranked, highlighted results. Queries support "phrases", OR and -exclusions. Each post's language is detected at ingest and searched with the matching stemmer.`,
+		Args:        []argSpec{{Name: "query", Variadic: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("feed", "", "only search posts from the feed with this URL")
+			fs.String("since", "", "only search posts published on or after this date")
+			fs.String("until", "", "only search posts published before this date")
+			fs.Bool("unread", false, "only search unread posts")
+			fs.Bool("read", false, "only search posts that have been read")
+			fs.String("tag", "", "only search posts with this tag, directly or via their feed")
+			fs.String("lang", "", "only search posts in this language (e.g. en, de, ja)")
+			fs.Int("limit", defaultSearchLimit, "maximum number of results")
+		},
+		FlagCompletions: map[string]completer{"feed": completeFollowedFeeds, "tag": completeTags},
+		Examples:        []string{`gator search '"connection pooling" pgbouncer' --since 2025-03-01`},
+		Records:         true,
+		Handler:         middlewareLoggedIn(handlerSearch),
+	})
+	c.register(&commandSpec{
+		Name:    "tui",
+		Summary: "Opens a full-screen reader for your feeds.",
+		Description: `Opens a full-screen, keyboard-driven reader: followed feeds and categories with unread counts on the left, posts on the right and the selected post below them.
+
+Keys: j/k or arrows move, g/G jump to the top or bottom, ctrl-d/ctrl-u page, enter or l opens, h or esc goes back, tab switches pane, n/p read the next or previous post, m toggles read, s toggles the star, o opens the post in a browser ($BROWSER if set), r fetches the selected feed now, a shows read posts too, R reloads and q quits.`,
+		Examples: []string{"gator tui"},
+		Handler:  middlewareLoggedIn(handlerTUI),
+	})
+	c.register(&commandSpec{
+		Name:        "serve",
+		Summary:     "Serves a JSON REST API and the Google Reader API over the gator database.",
+		Description: "Serves a JSON REST API under /api/v1 and a Google Reader compatible API for mobile clients. A server API key is generated and saved to the config on first run.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("addr", ":8080", "address to listen on")
+		},
+		Examples: []string{"gator serve --addr :8080"},
+		Handler:  handlerServe,
+	})
+	c.register(&commandSpec{
+		Name:    "apikey",
+		Summary: "Creates, lists or revokes your API keys.",
+		Subcommands: []*commandSpec{
+			{
+				Name:    "create",
+				Summary: "Creates an API key; the plaintext key is shown once.",
+				Args:    []argSpec{{Name: "label"}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("scope", auth.ScopeReadWrite, "key scope: read or read-write")
+				},
+				FlagCompletions: map[string]completer{"scope": completeWords(auth.ScopeRead, auth.ScopeReadWrite)},
+				Examples:        []string{"gator apikey create dashboard --scope read"},
+				Records:         true,
+				Handler:         middlewareLoggedIn(handlerAPIKeyCreate),
+			},
+			{
+				Name:    "list",
+				Summary: "Lists your API keys.",
+				Records: true,
+				Handler: middlewareLoggedIn(handlerAPIKeyList),
+			},
+			{
+				Name:    "revoke",
+				Summary: "Revokes an API key by ID or label.",
+				Args:    []argSpec{{Name: "id|label", Complete: completeAPIKeys}},
+				Records: true,
+				Handler: middlewareLoggedIn(handlerAPIKeyRevoke),
+			},
+		},
+	})
+	c.register(&commandSpec{
+		Name:        "publish",
+		Summary:     "Writes a timeline as an Atom 1.0 or RSS 2.0 feed.",
+		Description: "Writes a user's timeline, or a tag, category or feed within it, as an Atom 1.0 or RSS 2.0 feed. Defaults to the logged-in user and Atom on stdout.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("user", "", "publish this user's timeline (default: the logged-in user)")
+			fs.String("out", "", "write the feed to this file instead of standard output")
+			fs.String("format", publish.FormatAtom, "feed format: atom or rss")
+			fs.String("link", "", "URL the feed will be served from (required for rss)")
+			fs.String("tag", "", "only publish posts with this tag, directly or via their feed")
+			fs.String("category", "", "only publish posts from feeds in this category")
+			fs.String("feed", "", "only publish posts from the feed with this URL")
+			fs.String("lang", "", "only publish posts in this language (e.g. en, de, ja)")
+			fs.String("since", "", "only publish posts published on or after this date")
+			fs.Bool("unread", false, "only publish posts the user has not read")
+			fs.Int("limit", defaultPublishLimit, "maximum number of posts")
+		},
+		FlagCompletions: map[string]completer{
+			"user":     completeUsers,
+			"out":      completeFiles,
+			"format":   completeWords(publish.FormatAtom, publish.FormatRSS),
+			"tag":      completeTags,
+			"category": completeCategories,
+			"feed":     completeFeeds,
+		},
+		Examples: []string{"gator publish --user alice --category Engineering --out engineering.xml"},
+		Records:  true,
+		Handler:  handlerPublish,
+	})
+	c.register(&commandSpec{
+		Name:        "digest",
+		Summary:     "Renders a digest of unread posts, or emails it.",
+		Description: "Renders a user's unread posts published in a period, grouped by category and feed, from text and HTML templates. Prints the text version by default; with --send it is mailed as both over the SMTP server in ~/.gatorcli.json. Use --export-templates to get copies of the templates to edit.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("user", "", "build this user's digest (default: the logged-in user)")
+			fs.String("since", defaultDigestSince, "include posts fetched since this age or date")
+			fs.Bool("send", false, "email the digest instead of printing it")
+			fs.String("to", "", "address to send to (default: the one in the user's digest schedule)")
+			fs.Bool("html", false, "print the HTML version instead of the text")
+			fs.String("templates", "", "directory of templates to use instead of the configured ones")
+			fs.String("export-templates", "", "write the built-in templates to this directory and exit")
+		},
+		FlagCompletions: map[string]completer{
+			"user":             completeUsers,
+			"templates":        completeFiles,
+			"export-templates": completeFiles,
+		},
+		Examples: []string{
+			"gator digest --since 7d",
+			"gator digest --user alice --since 24h --send",
+		},
+		Records: true,
+		Handler: handlerDigest,
+	})
+	c.register(&commandSpec{
+		Name:        "digestschedule",
+		Summary:     "Shows, sets or turns off your emailed digest schedule.",
+		Description: `Sets when 'gator agg' mails you a digest of your unread posts: "daily at HH:MM" or "weekly on <day> at HH:MM", in the timezone given by --tz. Each digest covers the posts fetched since the previous one. With no schedule, shows the current one; "off" removes it.`,
+		Args:        []argSpec{{Name: "schedule|off", Optional: true, Variadic: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("to", "", "address to send digests to (default: the current one)")
+			fs.String("tz", "", "IANA timezone the time is in (default: the current one, or UTC)")
+		},
+		Examples: []string{
+			"gator digestschedule daily at 07:00 --to me@example.com --tz Europe/Berlin",
+			`gator digestschedule "weekly on friday at 17:30"`,
+			"gator digestschedule off",
+		},
+		Records: true,
+		Handler: middlewareLoggedIn(handlerDigestSchedule),
+	})
+	c.register(&commandSpec{
+		Name:        "completion",
+		Summary:     "Prints a shell completion script for bash, zsh or fish.",
+		Description: "Prints a shell completion script for bash, zsh or fish. Besides commands and flags, it completes feed URLs, user names, tags and categories from the database.",
+		Args:        []argSpec{{Name: "shell", Complete: completeWords("bash", "zsh", "fish")}},
+		Examples: []string{
+			"source <(gator completion bash)",
+			"gator completion zsh > \"${fpath[1]}/_gator\"",
+			"gator completion fish > ~/.config/fish/completions/gator.fish",
+		},
+		Handler: handlerCompletion,
+	})
+	c.register(helpCommand(c))
+	c.register(completeCommand(c))
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// completeCommandName is the hidden command the completion scripts call to
+// complete the word under the cursor.
+const completeCommandName = "__complete"
+
+// filesDirective tells the completion scripts to fall back to the shell's own
+// file name completion.
+const filesDirective = ":files"
+
+// completeTimeout bounds the database queries behind dynamic completions, so
+// a slow or unreachable database does not hang the shell.
+const completeTimeout = 2 * time.Second
+
+// candidate is one completion. zsh and fish show the description next to the
+// value; bash ignores it.
+type candidate struct {
+	Value       string
+	Description string
+}
+
+// completer produces the values an argument or flag can take.
+type completer func(ctx context.Context, s *state) ([]candidate, error)
+
+// completeWords completes a fixed set of values.
+func completeWords(words ...string) completer {
+	return func(ctx context.Context, s *state) ([]candidate, error) {
+		candidates := make([]candidate, len(words))
+		for i, w := range words {
+			candidates[i] = candidate{Value: w}
+		}
+		return candidates, nil
+	}
+}
+
+// completeFiles defers to the shell's file name completion.
+func completeFiles(ctx context.Context, s *state) ([]candidate, error) {
+	return []candidate{{Value: filesDirective}}, nil
+}
+
+func completeUsers(ctx context.Context, s *state) ([]candidate, error) {
+	users, err := s.DB.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(users))
+	for i, user := range users {
+		candidates[i] = candidate{Value: user.Name}
+	}
+	return candidates, nil
+}
+
+func completeFeeds(ctx context.Context, s *state) ([]candidate, error) {
+	feeds, err := s.DB.GetFeedsWithUserName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(feeds))
+	for i, feed := range feeds {
+		candidates[i] = candidate{Value: feed.Url, Description: feed.Name}
+	}
+	return candidates, nil
+}
+
+func completeFollowedFeeds(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	follows, err := s.DB.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(follows))
+	for i, follow := range follows {
+		candidates[i] = candidate{Value: follow.FeedUrl, Description: follow.FeedName}
+	}
+	return candidates, nil
+}
+
+// completeUnfollowedFeeds completes the feeds the current user could follow.
+func completeUnfollowedFeeds(ctx context.Context, s *state) ([]candidate, error) {
+	followed, err := completeFollowedFeeds(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool, len(followed))
+	for _, c := range followed {
+		skip[c.Value] = true
+	}
+
+	feeds, err := completeFeeds(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []candidate
+	for _, c := range feeds {
+		if !skip[c.Value] {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
+}
+
+func completeTags(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.DB.ListTagsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return completeWords(tags...)(ctx, s)
+}
+
+func completeCategories(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.DB.ListCategoriesForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return completeWords(categories...)(ctx, s)
+}
+
+func completeAPIKeys(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.DB.ListAPIKeysForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(keys))
+	for i, key := range keys {
+		candidates[i] = candidate{Value: key.Label, Description: key.Scope}
+	}
+	return candidates, nil
+}
+
+func completeRules(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.DB.ListRulesForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(list))
+	for i, r := range list {
+		candidates[i] = candidate{Value: r.Rule.Name, Description: r.Rule.Action}
+	}
+	return candidates, nil
+}
+
+func completeWebhooks(ctx context.Context, s *state) ([]candidate, error) {
+	user, err := currentUser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := s.DB.ListWebhooksForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]candidate, len(hooks))
+	for i, hook := range hooks {
+		candidates[i] = candidate{Value: hook.ID.String(), Description: hook.Url}
+	}
+	return candidates, nil
+}
+
+// complete returns the candidates for the last word of a command line, given
+// without the leading "gator". The last word is the (possibly empty) word
+// under the cursor.
+func complete(ctx context.Context, s *state, registry *commands, words []string) []candidate {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	toComplete := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	formats := completeWords("text", "json", "jsonl", "csv", "table")
+	if n := len(words); n > 0 && isOutputFlag(words[n-1]) {
+		return runCompleter(ctx, s, formats, "", toComplete)
+	}
+	if name, value, ok := strings.Cut(toComplete, "="); ok && isOutputFlag(name) {
+		return runCompleter(ctx, s, formats, name+"=", value)
+	}
+	if rest, _, err := extractOutputFlag(registry, words); err == nil {
+		words = rest
+	}
+
+	if len(words) == 0 {
+		if strings.HasPrefix(toComplete, "-") {
+			return filterCandidates([]candidate{
+				{Value: "--output", Description: "output format"},
+				{Value: "--help", Description: "show help"},
+			}, "", toComplete)
+		}
+		return filterCandidates(commandCandidates(registry.specs), "", toComplete)
+	}
+
+	spec := findSpec(registry.specs, strings.ToLower(words[0]))
+	if spec == nil {
+		return nil
+	}
+	if spec.Name == "help" {
+		return completeHelp(registry, words[1:], toComplete)
+	}
+
+	path, rest := spec.Name, words[1:]
+	for len(spec.Subcommands) > 0 {
+		if len(rest) == 0 {
+			return filterCandidates(commandCandidates(spec.Subcommands), "", toComplete)
+		}
+		sub := spec.subcommand(rest[0])
+		if sub == nil {
+			return nil
+		}
+		spec, path, rest = sub, path+" "+sub.Name, rest[1:]
+	}
+	if spec.RawArgs {
+		return nil
+	}
+
+	fs := spec.flagSet(path)
+	if n := len(rest); n > 0 {
+		if f := valueFlag(fs, rest[n-1]); f != nil {
+			return runCompleter(ctx, s, spec.FlagCompletions[f.Name], "", toComplete)
+		}
+	}
+	if strings.HasPrefix(toComplete, "-") {
+		if name, value, ok := strings.Cut(toComplete, "="); ok {
+			f := valueFlag(fs, name)
+			if f == nil {
+				return nil
+			}
+			return runCompleter(ctx, s, spec.FlagCompletions[f.Name], name+"=", value)
+		}
+		return filterCandidates(flagCandidates(fs), "", toComplete)
+	}
+
+	arg, ok := spec.argAt(positionalCount(fs, rest))
+	if !ok {
+		return nil
+	}
+	return runCompleter(ctx, s, arg.Complete, "", toComplete)
+}
+
+// runCompleter calls the completer, if any, and keeps the values that start
+// with toComplete. Values are returned with prefix, for --flag=value words.
+// Errors, such as nobody being logged in, simply mean no candidates.
+func runCompleter(ctx context.Context, s *state, c completer, prefix, toComplete string) []candidate {
+	if c == nil {
+		return nil
+	}
+	candidates, err := c(ctx, s)
+	if err != nil {
+		return nil
+	}
+	return filterCandidates(candidates, prefix, toComplete)
+}
+
+func filterCandidates(candidates []candidate, prefix, toComplete string) []candidate {
+	var out []candidate
+	for _, c := range candidates {
+		if c.Value == filesDirective {
+			return []candidate{c}
+		}
+		if strings.HasPrefix(c.Value, toComplete) {
+			out = append(out, candidate{Value: prefix + c.Value, Description: c.Description})
+		}
+	}
+	return out
+}
+
+// completeHelp completes the command path after "gator help".
+func completeHelp(registry *commands, words []string, toComplete string) []candidate {
+	specs := registry.specs
+	for _, name := range words {
+		spec := findSpec(specs, strings.ToLower(name))
+		if spec == nil {
+			return nil
+		}
+		specs = spec.Subcommands
+	}
+	return filterCandidates(commandCandidates(specs), "", toComplete)
+}
+
+func commandCandidates(specs []*commandSpec) []candidate {
+	var candidates []candidate
+	for _, spec := range specs {
+		if !spec.Hidden {
+			candidates = append(candidates, candidate{Value: spec.Name, Description: spec.Summary})
+		}
+	}
+	return candidates
+}
+
+func flagCandidates(fs *flag.FlagSet) []candidate {
+	var candidates []candidate
+	fs.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		candidates = append(candidates, candidate{Value: "--" + f.Name, Description: usage})
+	})
+	return append(candidates, candidate{Value: "--help", Description: "show help for this command"})
+}
+
+func isOutputFlag(word string) bool {
+	return word == "-o" || word == "--output" || word == "-output"
+}
+
+// valueFlag returns the flag named by word if it takes a separate value, such
+// as "--category" but not "--all" or "--category=News".
+func valueFlag(fs *flag.FlagSet, word string) *flag.Flag {
+	if !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil
+	}
+	f := fs.Lookup(strings.TrimLeft(word, "-"))
+	if f == nil {
+		return nil
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return nil
+	}
+	return f
+}
+
+// positionalCount counts the positional arguments in words, skipping flags
+// and their values.
+func positionalCount(fs *flag.FlagSet, words []string) int {
+	n := 0
+	for i := 0; i < len(words); i++ {
+		switch {
+		case valueFlag(fs, words[i]) != nil:
+			i++
+		case strings.HasPrefix(words[i], "-") && words[i] != "-":
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// writeCandidates prints one candidate per line, with its description after
+// a tab.
+func writeCandidates(w io.Writer, candidates []candidate) error {
+	for _, c := range candidates {
+		line := c.Value
+		if desc := strings.Join(strings.Fields(c.Description), " "); desc != "" {
+			line += "\t" + desc
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeCommand builds the hidden command the completion scripts call. It
+// receives the command line as typed, so the registry does not parse it.
+func completeCommand(registry *commands) *commandSpec {
+	return &commandSpec{
+		Name:    completeCommandName,
+		Summary: "Prints completions for a partial command line.",
+		Hidden:  true,
+		RawArgs: true,
+		Handler: func(s *state, cmd command) error {
+			words := cmd.Args
+			if len(words) > 0 && words[0] == "--" {
+				words = words[1:]
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), completeTimeout)
+			defer cancel()
+			return writeCandidates(os.Stdout, complete(ctx, s, registry, words))
+		},
+	}
+}
+
+func handlerCompletion(s *state, cmd command) error {
+	switch shell := cmd.Args[0]; shell {
+	case "bash":
+		_, err := io.WriteString(os.Stdout, bashCompletion)
+		return err
+	case "zsh":
+		_, err := io.WriteString(os.Stdout, zshCompletion)
+		return err
+	case "fish":
+		_, err := io.WriteString(os.Stdout, fishCompletion)
+		return err
+	default:
+		return fmt.Errorf("unsupported shell '%s': expected bash, zsh or fish", shell)
+	}
+}
+
+// The scripts pass the words typed so far to "gator __complete", which
+// resolves them against the command registry and the database.
+
+const bashCompletion = `# bash completion for gator
+_gator() {
+    local line=${COMP_LINE:0:COMP_POINT} words=() out value
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] || ${#words[@]} -eq 0 ]] && words+=("")
+    local cur=${words[${#words[@]}-1]}
+
+    out=$(gator __complete -- "${words[@]:1}" 2>/dev/null)
+    COMPREPLY=()
+    if [[ $out == ":files" ]]; then
+        compopt -o default
+        return
+    fi
+
+    # bash splits words on ':' and '=', so URLs and --flag=value words are
+    # completed from the last such separator.
+    local prefix=${cur%"${cur##*[:=]}"}
+    while IFS= read -r value; do
+        value=${value%%$'\t'*}
+        [[ -n $value ]] && COMPREPLY+=("${value#"$prefix"}")
+    done <<< "$out"
+}
+complete -F _gator gator
+`
+
+const zshCompletion = `#compdef gator
+
+_gator() {
+    local -a lines values
+    local line
+    lines=("${(@f)$(gator __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ ${lines[1]} == ":files" ]]; then
+        _files
+        return
+    fi
+    for line in $lines; do
+        [[ -z $line ]] && continue
+        if [[ $line == *$'\t'* ]]; then
+            values+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            values+=("${line//:/\\:}")
+        fi
+    done
+    _describe 'gator' values
+}
+
+if [[ $funcstack[1] == _gator ]]; then
+    _gator "$@"
+else
+    compdef _gator gator
+fi
+`
+
+const fishCompletion = `# fish completion for gator
+function __gator_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l out (gator __complete -- $args 2>/dev/null)
+    if test "$out" = ":files"
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out
+end
+
+complete -c gator -f -a '(__gator_complete)'
+`
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/feed"
+)
+
+// Domain errors returned by command handlers. Callers should match them with
+// errors.Is rather than inspecting driver error strings.
+var (
+	ErrFeedNotFound     = errors.New("feed not found")
+	ErrFeedExists       = errors.New("a feed with this URL already exists")
+	ErrAlreadyFollowing = errors.New("you are already following this feed")
+	ErrNotFollowing     = errors.New("you are not following this feed")
+	ErrPostNotFound     = errors.New("post not found")
+	ErrInvalidPostRef   = errors.New("invalid post reference")
+	ErrRuleNotFound     = errors.New("rule not found")
+)
+
+// pqUniqueViolation is the Postgres SQLSTATE for unique_violation.
+const pqUniqueViolation = pq.ErrorCode("23505")
+
+// isUniqueViolation reports whether err is a Postgres unique-constraint violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
+
+// getFeedByURL looks up a feed by URL, translating a missing row into
+// ErrFeedNotFound. The URL is canonicalized first; a feed stored under the
+// other of http and https, or exactly as given, also matches.
+func getFeedByURL(ctx context.Context, q *database.Queries, c *feed.Canonicalizer, feedURL string) (database.Feed, error) {
+	candidates := []string{feedURL}
+	if canonical, err := c.Canonicalize(feedURL); err == nil {
+		candidates = append(feed.SchemeVariants(canonical), feedURL)
+	}
+	for _, u := range candidates {
+		f, err := q.GetFeedByUrl(ctx, u)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, fmt.Errorf("failed to look up feed: %w", err)
+		}
+	}
+	return database.Feed{}, fmt.Errorf("%w: %s", ErrFeedNotFound, feedURL)
+}
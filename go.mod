@@ -7,6 +7,8 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/mitchellh/go-homedir v1.1.0
 	golang.org/x/net v0.46.0
+	golang.org/x/term v0.36.0
 	golang.org/x/text v0.30.0
 )
 
+require golang.org/x/sys v0.37.0 // indirect
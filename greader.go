@@ -0,0 +1,673 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/auth"
+	"github.com/Numpkens/gatorcli/internal/database"
+)
+
+// The Google Reader API, as implemented by FreshRSS and spoken by mobile
+// clients such as Reeder and NetNewsWire. Clients log in with their gator
+// user name and a per-user API key, and identify items and streams with the
+// string IDs below.
+const (
+	readerItemPrefix  = "tag:google.com,2005:reader/item/"
+	readerFeedPrefix  = "feed/"
+	readerLabelPrefix = "user/-/label/"
+	readerStatePrefix = "user/-/state/com.google/"
+
+	readerReadingList = readerStatePrefix + "reading-list"
+	readerRead        = readerStatePrefix + "read"
+	readerStarred     = readerStatePrefix + "starred"
+	readerKeptUnread  = readerStatePrefix + "kept-unread"
+
+	readerStreamContentsPath = "/reader/api/0/stream/contents/"
+
+	defaultReaderItems = 20
+	maxReaderItems     = 1000
+)
+
+func (a *apiServer) readerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /accounts/ClientLogin", a.handleReaderLogin)
+
+	mux.HandleFunc("GET /reader/api/0/token", a.readerAuthed(false, a.handleReaderToken))
+	mux.HandleFunc("GET /reader/api/0/user-info", a.readerAuthed(false, a.handleReaderUserInfo))
+	mux.HandleFunc("GET /reader/api/0/subscription/list", a.readerAuthed(false, a.handleReaderSubscriptions))
+	mux.HandleFunc("POST /reader/api/0/subscription/edit", a.readerAuthed(true, a.handleReaderEditSubscription))
+	mux.HandleFunc("POST /reader/api/0/subscription/quickadd", a.readerAuthed(true, a.handleReaderQuickAdd))
+	mux.HandleFunc("GET /reader/api/0/tag/list", a.readerAuthed(false, a.handleReaderTags))
+	mux.HandleFunc("GET /reader/api/0/unread-count", a.readerAuthed(false, a.handleReaderUnreadCount))
+	mux.HandleFunc("GET /reader/api/0/stream/items/ids", a.readerAuthed(false, a.handleReaderItemIDs))
+	mux.HandleFunc("/reader/api/0/stream/items/contents", a.readerAuthed(false, a.handleReaderItemContents))
+	mux.HandleFunc("POST /reader/api/0/edit-tag", a.readerAuthed(true, a.handleReaderEditTag))
+	mux.HandleFunc("POST /reader/api/0/mark-all-as-read", a.readerAuthed(true, a.handleReaderMarkAllRead))
+}
+
+// withReaderStreams serves stream contents before the mux sees the request.
+// Stream IDs such as feed/https://example.com/rss are sent unescaped in the
+// path, and ServeMux would redirect to a cleaned path without the "//".
+func (a *apiServer) withReaderStreams(next http.Handler) http.Handler {
+	streamContents := a.readerAuthed(false, a.handleReaderStreamContents)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, readerStreamContentsPath) {
+			streamContents(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readerAuthed is the Google Reader counterpart of authed: it accepts a
+// GoogleLogin token (a per-user API key) and reports errors as plain text.
+// Clients fetch items with POST, so write marks the endpoints that change
+// data rather than relying on the method.
+func (a *apiServer) readerAuthed(write bool, handler apiHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := auth.GetGoogleLoginToken(r.Header)
+		if err != nil {
+			writeReaderError(w, unauthorized(err.Error()))
+			return
+		}
+		user, err := a.userForKey(r.Context(), key, write)
+		if err != nil {
+			writeReaderError(w, err)
+			return
+		}
+		if err := handler(w, r, user); err != nil {
+			writeReaderError(w, err)
+		}
+	}
+}
+
+func writeReaderError(w http.ResponseWriter, err error) {
+	status, msg := errorStatus(err)
+	http.Error(w, msg, status)
+}
+
+func writeReaderOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+// handleReaderLogin implements ClientLogin. Email is the gator user name and
+// Passwd one of that user's API keys, which is returned as the auth token.
+// Both are read from the POST body only, so a key never ends up in a URL and
+// from there in access logs or browser history.
+func (a *apiServer) handleReaderLogin(w http.ResponseWriter, r *http.Request) {
+	name := r.PostFormValue("Email")
+	key := r.PostFormValue("Passwd")
+	if name == "" || key == "" {
+		writeReaderError(w, unauthorized("Email and Passwd are required"))
+		return
+	}
+
+	user, err := a.userForKey(r.Context(), key, false)
+	if err != nil {
+		writeReaderError(w, err)
+		return
+	}
+	if user.Name != name {
+		writeReaderError(w, unauthorized("API key does not belong to this user"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=null\nAuth=%s\n", key, key)
+}
+
+// handleReaderToken returns the token clients send back with edits. Requests
+// are already authenticated by header, so it is not checked.
+func (a *apiServer) handleReaderToken(w http.ResponseWriter, r *http.Request, user database.User) error {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, strings.ReplaceAll(user.ID.String(), "-", ""))
+	return nil
+}
+
+func (a *apiServer) handleReaderUserInfo(w http.ResponseWriter, r *http.Request, user database.User) error {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"userId":        user.ID.String(),
+		"userName":      user.Name,
+		"userProfileId": user.ID.String(),
+		"userEmail":     user.Name,
+	})
+	return nil
+}
+
+type readerCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type readerSubscription struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Categories []readerCategory `json:"categories"`
+	URL        string           `json:"url"`
+	HTMLURL    string           `json:"htmlUrl"`
+	IconURL    string           `json:"iconUrl"`
+}
+
+func (a *apiServer) handleReaderSubscriptions(w http.ResponseWriter, r *http.Request, user database.User) error {
+	follows, err := a.state.DB.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed follows: %w", err)
+	}
+
+	subs := make([]readerSubscription, 0, len(follows))
+	for _, f := range follows {
+		sub := readerSubscription{
+			ID:         readerFeedPrefix + f.FeedUrl,
+			Title:      f.FeedName,
+			Categories: []readerCategory{},
+			URL:        f.FeedUrl,
+			HTMLURL:    f.FeedUrl,
+		}
+		if f.Category.Valid {
+			sub.Categories = append(sub.Categories, readerCategory{
+				ID:    readerLabelPrefix + f.Category.String,
+				Label: f.Category.String,
+			})
+		}
+		subs = append(subs, sub)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptions": subs})
+	return nil
+}
+
+// readerSubscribe follows feedURL, adding the feed first if nobody has yet.
+func (a *apiServer) readerSubscribe(ctx context.Context, userID uuid.UUID, feedURL, title string, category sql.NullString) error {
+	_, err := followFeed(ctx, a.state, userID, feedURL, category)
+	if !errors.Is(err, ErrFeedNotFound) {
+		return err
+	}
+
+	if title == "" {
+		title = feedURL
+	}
+	if _, _, err := addFeed(ctx, a.state, userID, title, feedURL); err != nil {
+		return err
+	}
+	if category.Valid {
+		_, err = moveFeed(ctx, a.state, userID, feedURL, category)
+	}
+	return err
+}
+
+func (a *apiServer) handleReaderEditSubscription(w http.ResponseWriter, r *http.Request, user database.User) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form: %v", err)
+	}
+	streams := r.PostForm["s"]
+	if len(streams) == 0 {
+		return badRequest("s is required")
+	}
+	addLabel := strings.TrimPrefix(normalizeStreamID(r.PostFormValue("a")), readerLabelPrefix)
+	removeLabel := r.PostFormValue("r")
+
+	ctx := r.Context()
+	for _, stream := range streams {
+		feedURL, ok := strings.CutPrefix(stream, readerFeedPrefix)
+		if !ok {
+			return badRequest("unsupported subscription '%s'", stream)
+		}
+
+		var err error
+		switch action := r.PostFormValue("ac"); action {
+		case "subscribe":
+			err = a.readerSubscribe(ctx, user.ID, feedURL, r.PostFormValue("t"), categoryParam(addLabel))
+		case "unsubscribe":
+			_, err = unfollowFeed(ctx, a.state, user.ID, feedURL)
+		case "edit":
+			// Feed titles are shared between users, so only the label can be
+			// edited.
+			switch {
+			case addLabel != "":
+				_, err = moveFeed(ctx, a.state, user.ID, feedURL, categoryParam(addLabel))
+			case removeLabel != "":
+				_, err = moveFeed(ctx, a.state, user.ID, feedURL, sql.NullString{})
+			}
+		default:
+			return badRequest("unsupported action '%s'", action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	writeReaderOK(w)
+	return nil
+}
+
+func (a *apiServer) handleReaderQuickAdd(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := strings.TrimPrefix(r.FormValue("quickadd"), readerFeedPrefix)
+	if feedURL == "" {
+		return badRequest("quickadd is required")
+	}
+	if err := a.readerSubscribe(r.Context(), user.ID, feedURL, "", sql.NullString{}); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"numResults": 1,
+		"query":      feedURL,
+		"streamId":   readerFeedPrefix + feedURL,
+	})
+	return nil
+}
+
+func (a *apiServer) handleReaderTags(w http.ResponseWriter, r *http.Request, user database.User) error {
+	ctx := r.Context()
+	categories, err := a.state.DB.ListCategoriesForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	tags, err := a.state.DB.ListTagsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	type readerTag struct {
+		ID   string `json:"id"`
+		Type string `json:"type,omitempty"`
+	}
+	list := []readerTag{{ID: readerStarred}}
+	for _, c := range categories {
+		list = append(list, readerTag{ID: readerLabelPrefix + c, Type: "folder"})
+	}
+	for _, t := range tags {
+		list = append(list, readerTag{ID: readerLabelPrefix + t, Type: "tag"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": list})
+	return nil
+}
+
+func (a *apiServer) handleReaderUnreadCount(w http.ResponseWriter, r *http.Request, user database.User) error {
+	follows, err := a.state.DB.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed follows: %w", err)
+	}
+
+	type unreadCount struct {
+		ID    string `json:"id"`
+		Count int64  `json:"count"`
+	}
+	var total int64
+	var counts []unreadCount
+	byCategory := map[string]int64{}
+	var categories []string
+	for _, f := range follows {
+		counts = append(counts, unreadCount{ID: readerFeedPrefix + f.FeedUrl, Count: f.UnreadCount})
+		total += f.UnreadCount
+		if f.Category.Valid {
+			if _, ok := byCategory[f.Category.String]; !ok {
+				categories = append(categories, f.Category.String)
+			}
+			byCategory[f.Category.String] += f.UnreadCount
+		}
+	}
+	for _, c := range categories {
+		counts = append(counts, unreadCount{ID: readerLabelPrefix + c, Count: byCategory[c]})
+	}
+	counts = append(counts, unreadCount{ID: readerReadingList, Count: total})
+
+	writeJSON(w, http.StatusOK, map[string]any{"max": maxReaderItems, "unreadcounts": counts})
+	return nil
+}
+
+// normalizeStreamID rewrites user/<id>/... stream IDs to the user/-/... form,
+// since clients may use either.
+func normalizeStreamID(id string) string {
+	rest, ok := strings.CutPrefix(id, "user/")
+	if !ok {
+		return id
+	}
+	_, rest, ok = strings.Cut(rest, "/")
+	if !ok {
+		return id
+	}
+	return "user/-/" + rest
+}
+
+// streamParams builds the query for a stream ID and the standard stream
+// parameters: n (count), r=o (oldest first), c (continuation), xt (exclude
+// target), ot and nt (oldest and newest time, in seconds).
+func (a *apiServer) streamParams(r *http.Request, userID uuid.UUID, stream string) (database.GetStreamPostsParams, error) {
+	params := database.GetStreamPostsParams{
+		UserID:      userID,
+		OldestFirst: r.FormValue("r") == "o",
+		Lim:         defaultReaderItems,
+	}
+
+	switch stream = normalizeStreamID(stream); {
+	case stream == readerReadingList:
+	case stream == readerStarred:
+		params.StarredOnly = true
+	case stream == readerRead:
+		params.IsRead = sql.NullBool{Bool: true, Valid: true}
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		params.Label = sql.NullString{String: strings.TrimPrefix(stream, readerLabelPrefix), Valid: true}
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		feed, err := getFeedByURL(r.Context(), a.state.DB, a.state.URLs, strings.TrimPrefix(stream, readerFeedPrefix))
+		if err != nil {
+			return params, err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	default:
+		return params, badRequest("unsupported stream '%s'", stream)
+	}
+
+	if n := r.FormValue("n"); n != "" {
+		count, err := strconv.Atoi(n)
+		if err != nil || count <= 0 {
+			return params, badRequest("invalid n '%s': must be a positive integer", n)
+		}
+		params.Lim = int32(min(count, maxReaderItems))
+	}
+	if c := r.FormValue("c"); c != "" {
+		offset, err := strconv.Atoi(c)
+		if err != nil || offset < 0 {
+			return params, badRequest("invalid continuation '%s'", c)
+		}
+		params.Off = int32(offset)
+	}
+	if normalizeStreamID(r.FormValue("xt")) == readerRead {
+		params.IsRead = sql.NullBool{Bool: false, Valid: true}
+	}
+	for name, dest := range map[string]*sql.NullTime{"ot": &params.NewerThan, "nt": &params.OlderThan} {
+		value := r.FormValue(name)
+		if value == "" {
+			continue
+		}
+		secs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return params, badRequest("invalid %s '%s': must be a Unix timestamp", name, value)
+		}
+		*dest = sql.NullTime{Time: time.Unix(secs, 0).UTC(), Valid: true}
+	}
+	return params, nil
+}
+
+// continuation returns the token for the page after posts, or "" when
+// posts was the last page.
+func continuation(params database.GetStreamPostsParams, posts []database.GetStreamPostsRow) string {
+	if len(posts) < int(params.Lim) {
+		return ""
+	}
+	return strconv.Itoa(int(params.Off) + len(posts))
+}
+
+func (a *apiServer) handleReaderItemIDs(w http.ResponseWriter, r *http.Request, user database.User) error {
+	params, err := a.streamParams(r, user.ID, r.FormValue("s"))
+	if err != nil {
+		return err
+	}
+	posts, err := a.state.DB.GetStreamPosts(r.Context(), params)
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	type itemRef struct {
+		ID            string `json:"id"`
+		TimestampUsec string `json:"timestampUsec"`
+	}
+	refs := make([]itemRef, 0, len(posts))
+	for _, p := range posts {
+		refs = append(refs, itemRef{
+			ID:            strconv.FormatInt(p.Seq, 10),
+			TimestampUsec: strconv.FormatInt(p.PublishedAt.UnixMicro(), 10),
+		})
+	}
+	body := map[string]any{"itemRefs": refs}
+	if c := continuation(params, posts); c != "" {
+		body["continuation"] = c
+	}
+	writeJSON(w, http.StatusOK, body)
+	return nil
+}
+
+func (a *apiServer) handleReaderStreamContents(w http.ResponseWriter, r *http.Request, user database.User) error {
+	stream := strings.TrimPrefix(r.URL.Path, readerStreamContentsPath)
+	params, err := a.streamParams(r, user.ID, stream)
+	if err != nil {
+		return err
+	}
+	posts, err := a.state.DB.GetStreamPosts(r.Context(), params)
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	body := readerStream(stream, posts)
+	if c := continuation(params, posts); c != "" {
+		body["continuation"] = c
+	}
+	writeJSON(w, http.StatusOK, body)
+	return nil
+}
+
+// handleReaderItemContents returns the items named by the repeated i
+// parameter, which clients send as a GET query or a POST form.
+func (a *apiServer) handleReaderItemContents(w http.ResponseWriter, r *http.Request, user database.User) error {
+	seqs, err := readerItemSeqs(r)
+	if err != nil {
+		return err
+	}
+	posts, err := a.state.DB.GetStreamPosts(r.Context(), database.GetStreamPostsParams{
+		UserID: user.ID,
+		Seqs:   seqs,
+		Lim:    int32(len(seqs)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	writeJSON(w, http.StatusOK, readerStream(readerReadingList, posts))
+	return nil
+}
+
+// readerItemSeqs parses the i parameters into post seqs. Items may use the
+// long tag: form, whose suffix is hex, or the short decimal form.
+func readerItemSeqs(r *http.Request) ([]int64, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, badRequest("invalid form: %v", err)
+	}
+	ids := r.Form["i"]
+	if len(ids) == 0 {
+		return nil, badRequest("i is required")
+	}
+	if len(ids) > maxReaderItems {
+		return nil, badRequest("at most %d items may be requested at once", maxReaderItems)
+	}
+
+	seqs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		var seq int64
+		var err error
+		if hex, ok := strings.CutPrefix(id, readerItemPrefix); ok {
+			var u uint64
+			u, err = strconv.ParseUint(hex, 16, 64)
+			seq = int64(u)
+		} else {
+			seq, err = strconv.ParseInt(id, 10, 64)
+		}
+		if err != nil || seq <= 0 {
+			return nil, fmt.Errorf("%w '%s': expected a reader item ID", ErrInvalidPostRef, id)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, nil
+}
+
+type readerLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type readerItem struct {
+	ID            string       `json:"id"`
+	CrawlTimeMsec string       `json:"crawlTimeMsec"`
+	TimestampUsec string       `json:"timestampUsec"`
+	Published     int64        `json:"published"`
+	Updated       int64        `json:"updated"`
+	Title         string       `json:"title"`
+	Author        string       `json:"author,omitempty"`
+	Canonical     []readerLink `json:"canonical"`
+	Alternate     []readerLink `json:"alternate"`
+	Summary       struct {
+		Content string `json:"content"`
+	} `json:"summary"`
+	Categories []string `json:"categories"`
+	Origin     struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		HTMLURL  string `json:"htmlUrl"`
+	} `json:"origin"`
+}
+
+func readerStream(stream string, posts []database.GetStreamPostsRow) map[string]any {
+	items := make([]readerItem, 0, len(posts))
+	for _, p := range posts {
+		item := readerItem{
+			ID:            fmt.Sprintf("%s%016x", readerItemPrefix, p.Seq),
+			CrawlTimeMsec: strconv.FormatInt(p.CreatedAt.UnixMilli(), 10),
+			TimestampUsec: strconv.FormatInt(p.PublishedAt.UnixMicro(), 10),
+			Published:     p.PublishedAt.Unix(),
+			Updated:       p.UpdatedAt.Unix(),
+			Title:         p.Title,
+			Author:        p.Author.String,
+			Canonical:     []readerLink{{Href: p.Url}},
+			Alternate:     []readerLink{{Href: p.Url, Type: "text/html"}},
+			Categories:    []string{readerReadingList},
+		}
+		item.Summary.Content = postBody(p.Description, p.Content)
+		item.Origin.StreamID = readerFeedPrefix + p.FeedUrl
+		item.Origin.Title = p.FeedName
+		item.Origin.HTMLURL = p.FeedUrl
+
+		if p.IsRead {
+			item.Categories = append(item.Categories, readerRead)
+		}
+		if p.IsStarred {
+			item.Categories = append(item.Categories, readerStarred)
+		}
+		if p.Category.Valid {
+			item.Categories = append(item.Categories, readerLabelPrefix+p.Category.String)
+		}
+		for _, tag := range p.Tags {
+			item.Categories = append(item.Categories, readerLabelPrefix+tag)
+		}
+		items = append(items, item)
+	}
+
+	return map[string]any{
+		"direction": "ltr",
+		"id":        stream,
+		"updated":   time.Now().Unix(),
+		"items":     items,
+	}
+}
+
+// handleReaderEditTag adds (a) and removes (r) states and labels on the items
+// named by i. Read and starred map onto gator's read and star state, labels
+// onto post tags; other states are ignored.
+func (a *apiServer) handleReaderEditTag(w http.ResponseWriter, r *http.Request, user database.User) error {
+	seqs, err := readerItemSeqs(r)
+	if err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+	now := time.Now().UTC()
+	err = a.state.withTx(ctx, func(q *database.Queries) error {
+		for _, seq := range seqs {
+			for _, tag := range r.Form["a"] {
+				if err := applyReaderTag(ctx, q, user.ID, seq, tag, true, now); err != nil {
+					return err
+				}
+			}
+			for _, tag := range r.Form["r"] {
+				if err := applyReaderTag(ctx, q, user.ID, seq, tag, false, now); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	writeReaderOK(w)
+	return nil
+}
+
+func applyReaderTag(ctx context.Context, q *database.Queries, userID uuid.UUID, seq int64, tag string, add bool, now time.Time) error {
+	var err error
+	switch tag = normalizeStreamID(tag); {
+	case tag == readerRead && add:
+		_, err = q.MarkPostsRead(ctx, database.MarkPostsReadParams{UserID: userID, ReadAt: now, FromSeq: seq, ToSeq: seq})
+	case tag == readerRead, tag == readerKeptUnread && add:
+		_, err = q.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{UserID: userID, FromSeq: seq, ToSeq: seq})
+	case tag == readerStarred && add:
+		_, err = q.StarPosts(ctx, database.StarPostsParams{UserID: userID, StarredAt: now, FromSeq: seq, ToSeq: seq})
+	case tag == readerStarred:
+		_, err = q.UnstarPosts(ctx, database.UnstarPostsParams{UserID: userID, FromSeq: seq, ToSeq: seq})
+	case strings.HasPrefix(tag, readerLabelPrefix):
+		var tags []string
+		tags, err = normalizeTags([]string{strings.TrimPrefix(tag, readerLabelPrefix)})
+		if err != nil {
+			return badRequest("%v", err)
+		}
+		if add {
+			_, err = q.TagPosts(ctx, database.TagPostsParams{UserID: userID, CreatedAt: now, Tags: tags, FromSeq: seq, ToSeq: seq})
+		} else {
+			_, err = q.UntagPosts(ctx, database.UntagPostsParams{UserID: userID, Tags: tags, FromSeq: seq, ToSeq: seq})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update post %d: %w", seq, err)
+	}
+	return nil
+}
+
+// handleReaderMarkAllRead marks a feed, label or the whole reading list read,
+// optionally only up to ts (in microseconds).
+func (a *apiServer) handleReaderMarkAllRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+	}
+
+	switch stream := normalizeStreamID(r.FormValue("s")); {
+	case stream == readerReadingList:
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		params.Label = sql.NullString{String: strings.TrimPrefix(stream, readerLabelPrefix), Valid: true}
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		feed, err := getFeedByURL(r.Context(), a.state.DB, a.state.URLs, strings.TrimPrefix(stream, readerFeedPrefix))
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	default:
+		return badRequest("unsupported stream '%s'", stream)
+	}
+
+	if ts := r.FormValue("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return badRequest("invalid ts '%s': must be a timestamp in microseconds", ts)
+		}
+		params.Before = sql.NullTime{Time: time.UnixMicro(usec).UTC(), Valid: true}
+	}
+
+	if _, err := a.state.DB.MarkAllPostsRead(r.Context(), params); err != nil {
+		return fmt.Errorf("failed to mark posts read: %w", err)
+	}
+	writeReaderOK(w)
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/auth"
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+// apiKeyCreated is the record apikey create prints for --output. It is the
+// only time the key itself is shown.
+type apiKeyCreated struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Label     string    `json:"label"`
+	Scope     string    `json:"scope"`
+	Key       string    `json:"key"`
+}
+
+// apiKeyRevoked is the record apikey revoke prints for --output.
+type apiKeyRevoked struct {
+	Ref     string `json:"ref"`
+	Revoked int64  `json:"revoked"`
+}
+
+func handlerAPIKeyCreate(s *state, cmd command, user database.User) error {
+	label := cmd.Args[0]
+	scope := cmd.String("scope")
+	if !auth.ValidScope(scope) {
+		return fmt.Errorf("invalid scope '%s': expected %s or %s", scope, auth.ScopeRead, auth.ScopeReadWrite)
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := s.DB.CreateAPIKey(context.Background(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		KeyHash:   auth.HashKey(key),
+		Label:     label,
+		Scope:     scope,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("you already have an API key labelled '%s'", label)
+		}
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, apiKeyCreated{
+			ID:        apiKey.ID,
+			CreatedAt: apiKey.CreatedAt,
+			Label:     apiKey.Label,
+			Scope:     apiKey.Scope,
+			Key:       key,
+		})
+	}
+	fmt.Printf("Created %s API key '%s' (ID: %s).\n", apiKey.Scope, apiKey.Label, apiKey.ID)
+	fmt.Println("Store it now; it will not be shown again:")
+	fmt.Printf("  %s\n", key)
+	return nil
+}
+
+func handlerAPIKeyList(s *state, cmd command, user database.User) error {
+	keys, err := s.DB.ListAPIKeysForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch API keys: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, keys)
+	}
+
+	if len(keys) == 0 {
+		fmt.Println("You have no API keys. Use 'gator apikey create <label>' to make one.")
+		return nil
+	}
+
+	fmt.Printf("You have %d API keys:\n", len(keys))
+	for _, key := range keys {
+		lastUsed := "never"
+		if key.LastUsedAt.Valid {
+			lastUsed = key.LastUsedAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  - %s [%s]\n", key.Label, key.Scope)
+		fmt.Printf("      ID: %s | created %s | last used %s\n", key.ID, key.CreatedAt.Format("2006-01-02 15:04"), lastUsed)
+	}
+	return nil
+}
+
+func handlerAPIKeyRevoke(s *state, cmd command, user database.User) error {
+	ref := cmd.Args[0]
+
+	revoked, err := s.DB.DeleteAPIKey(context.Background(), database.DeleteAPIKeyParams{
+		UserID: user.ID,
+		Ref:    ref,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("no API key with ID or label '%s'", ref)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, apiKeyRevoked{Ref: ref, Revoked: revoked})
+	}
+	fmt.Printf("Revoked API key '%s'.\n", ref)
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/feed"
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+// errDryRun rolls back the dedupe transaction after a dry run has counted
+// what it would change.
+var errDryRun = errors.New("dry run")
+
+// dedupeStats counts what a dedupe run changed. It is also the record dedupe
+// prints for --output.
+type dedupeStats struct {
+	DryRun       bool  `json:"dry_run"`
+	FeedsMerged  int   `json:"feeds_merged"`
+	FeedsRenamed int   `json:"feeds_renamed"`
+	PostsMerged  int   `json:"posts_merged"`
+	PostsRekeyed int   `json:"posts_rekeyed"`
+	FollowsMoved int64 `json:"follows_moved"`
+	ReadsMoved   int64 `json:"reads_moved"`
+	StarsMoved   int64 `json:"stars_moved"`
+	HidesMoved   int64 `json:"hides_moved"`
+	TagsMoved    int64 `json:"tags_moved"`
+	RulesMoved   int64 `json:"rules_moved"`
+	HooksMoved   int64 `json:"hooks_moved"`
+}
+
+func handlerDedupe(s *state, cmd command) error {
+	dryRun := cmd.Bool("dry-run")
+	ctx := context.Background()
+
+	var stats dedupeStats
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		stats, err = dedupe(ctx, q, s.URLs, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return err
+	}
+
+	if s.Output != output.Text {
+		stats.DryRun = dryRun
+		return output.Write(os.Stdout, s.Output, stats)
+	}
+	if dryRun {
+		fmt.Println("Dry run; nothing was changed.")
+	}
+	fmt.Printf("Merged %d duplicate feeds and %d duplicate posts.\n", stats.FeedsMerged, stats.PostsMerged)
+	fmt.Printf("Canonicalized %d feed URLs and %d post links.\n", stats.FeedsRenamed, stats.PostsRekeyed)
+	fmt.Printf("Moved %d follows, %d reads, %d stars, %d hides, %d tags, %d rules and %d webhooks.\n",
+		stats.FollowsMoved, stats.ReadsMoved, stats.StarsMoved, stats.HidesMoved, stats.TagsMoved, stats.RulesMoved, stats.HooksMoved)
+	return nil
+}
+
+// dedupe rewrites every feed URL and post link to its canonical form and
+// merges the feeds and posts that turn out to be the same. The oldest feed
+// and post of each group are kept; follows, tags, rules, webhooks, reads and
+// stars move to them before the others are deleted. It is meant to run in a
+// transaction.
+func dedupe(ctx context.Context, q *database.Queries, c *feed.Canonicalizer, now time.Time) (dedupeStats, error) {
+	var stats dedupeStats
+
+	feeds, err := q.ListFeeds(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list feeds: %w", err)
+	}
+
+	// Group feeds by canonical URL, treating http and https as the same. The
+	// kept feed uses https if any feed in its group did.
+	keep := map[uuid.UUID]uuid.UUID{}
+	keptURL := map[uuid.UUID]string{}
+	byKey := map[string]uuid.UUID{}
+	for _, f := range feeds {
+		canonical, err := c.Canonicalize(f.Url)
+		if err != nil {
+			keep[f.ID] = f.ID
+			continue
+		}
+		key := strings.TrimPrefix(strings.TrimPrefix(canonical, "https://"), "http://")
+		target, ok := byKey[key]
+		if !ok {
+			byKey[key] = f.ID
+			keep[f.ID] = f.ID
+			keptURL[f.ID] = canonical
+			continue
+		}
+		keep[f.ID] = target
+		if strings.HasPrefix(canonical, "https://") {
+			keptURL[target] = canonical
+		}
+	}
+
+	if err := dedupePosts(ctx, q, c, keep, &stats); err != nil {
+		return stats, err
+	}
+
+	// Every post has moved off the merged feeds, so they can go.
+	for _, f := range feeds {
+		target := keep[f.ID]
+		if target == f.ID {
+			continue
+		}
+		moved, err := q.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+			ToFeedID:   target,
+			UpdatedAt:  now,
+			FromFeedID: f.ID,
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to move follows of %s: %w", f.Url, err)
+		}
+		stats.FollowsMoved += moved
+		moved, err = q.MoveFeedTags(ctx, database.MoveFeedTagsParams{FromFeedID: f.ID, ToFeedID: target})
+		if err != nil {
+			return stats, fmt.Errorf("failed to move tags of %s: %w", f.Url, err)
+		}
+		stats.TagsMoved += moved
+		moved, err = q.MoveFeedRules(ctx, database.MoveFeedRulesParams{ToFeedID: target, UpdatedAt: now, FromFeedID: f.ID})
+		if err != nil {
+			return stats, fmt.Errorf("failed to move rules of %s: %w", f.Url, err)
+		}
+		stats.RulesMoved += moved
+		moved, err = q.MoveFeedWebhooks(ctx, database.MoveFeedWebhooksParams{ToFeedID: target, FromFeedID: f.ID})
+		if err != nil {
+			return stats, fmt.Errorf("failed to move webhooks of %s: %w", f.Url, err)
+		}
+		stats.HooksMoved += moved
+		if err := q.DeleteFeed(ctx, f.ID); err != nil {
+			return stats, fmt.Errorf("failed to delete feed %s: %w", f.Url, err)
+		}
+		stats.FeedsMerged++
+	}
+
+	for _, f := range feeds {
+		canonical, ok := keptURL[f.ID]
+		if !ok || canonical == f.Url {
+			continue
+		}
+		err := q.SetFeedUrl(ctx, database.SetFeedUrlParams{Url: canonical, UpdatedAt: now, ID: f.ID})
+		if err != nil {
+			return stats, fmt.Errorf("failed to update feed URL %s: %w", f.Url, err)
+		}
+		stats.FeedsRenamed++
+	}
+	return stats, nil
+}
+
+// dedupePosts moves posts onto their kept feed with canonical links, merging
+// posts that end up with the same identity into the oldest of them.
+func dedupePosts(ctx context.Context, q *database.Queries, c *feed.Canonicalizer, keep map[uuid.UUID]uuid.UUID, stats *dedupeStats) error {
+	posts, err := q.ListPostIdentities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	type identity struct {
+		feedID uuid.UUID
+		key    string
+	}
+	targets := make([]database.SetPostIdentityParams, len(posts))
+	first := map[identity]uuid.UUID{}
+	for i, p := range posts {
+		t := database.SetPostIdentityParams{FeedID: keep[p.FeedID], Url: p.Url, ItemKey: p.ItemKey, ID: p.ID}
+		if canonical, err := c.Canonicalize(p.Url); err == nil {
+			t.Url = canonical
+			if strings.HasPrefix(p.ItemKey, "link:") {
+				t.ItemKey = "link:" + canonical
+			}
+		}
+		targets[i] = t
+		if _, ok := first[identity{t.FeedID, t.ItemKey}]; !ok {
+			first[identity{t.FeedID, t.ItemKey}] = p.ID
+		}
+	}
+
+	// Merge duplicates first so that no update below collides with a post
+	// that is about to go.
+	for _, t := range targets {
+		kept := first[identity{t.FeedID, t.ItemKey}]
+		if kept == t.ID {
+			continue
+		}
+		if err := mergePost(ctx, q, t.ID, kept, stats); err != nil {
+			return err
+		}
+	}
+
+	for i, p := range posts {
+		t := targets[i]
+		if first[identity{t.FeedID, t.ItemKey}] != p.ID {
+			continue
+		}
+		if t.FeedID == p.FeedID && t.Url == p.Url && t.ItemKey == p.ItemKey {
+			continue
+		}
+		if err := q.SetPostIdentity(ctx, t); err != nil {
+			return fmt.Errorf("failed to update post %s: %w", p.Url, err)
+		}
+		stats.PostsRekeyed++
+	}
+	return nil
+}
+
+// mergePost moves reads, stars, hides and tags from one post to another and deletes
+// it.
+func mergePost(ctx context.Context, q *database.Queries, from, to uuid.UUID, stats *dedupeStats) error {
+	moved, err := q.MovePostStars(ctx, database.MovePostStarsParams{FromPostID: from, ToPostID: to})
+	if err != nil {
+		return fmt.Errorf("failed to move stars: %w", err)
+	}
+	stats.StarsMoved += moved
+	moved, err = q.MovePostReads(ctx, database.MovePostReadsParams{FromPostID: from, ToPostID: to})
+	if err != nil {
+		return fmt.Errorf("failed to move reads: %w", err)
+	}
+	stats.ReadsMoved += moved
+	moved, err = q.MovePostHides(ctx, database.MovePostHidesParams{FromPostID: from, ToPostID: to})
+	if err != nil {
+		return fmt.Errorf("failed to move hides: %w", err)
+	}
+	stats.HidesMoved += moved
+	moved, err = q.MovePostTags(ctx, database.MovePostTagsParams{FromPostID: from, ToPostID: to})
+	if err != nil {
+		return fmt.Errorf("failed to move tags: %w", err)
+	}
+	stats.TagsMoved += moved
+	if err := q.DeletePost(ctx, from); err != nil {
+		return fmt.Errorf("failed to delete duplicate post: %w", err)
+	}
+	stats.PostsMerged++
+	return nil
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/digest"
+	"github.com/Numpkens/gatorcli/internal/feed"
+	"github.com/Numpkens/gatorcli/internal/mailer"
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+// A digest lists at most maxDigestPosts posts, each with a summary of up to
+// digestSummaryLength runes.
+const (
+	maxDigestPosts      = 200
+	digestSummaryLength = 280
+)
+
+const defaultDigestSince = "24h"
+
+var errNoSMTP = errors.New(`no mail server configured: add an "smtp" section to ~/.gatorcli.json`)
+
+// digestResult is the record digest prints for --output. To is set and Sent
+// is true once the digest has been mailed.
+type digestResult struct {
+	User    string    `json:"user"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	Posts   int       `json:"posts"`
+	Subject string    `json:"subject"`
+	To      string    `json:"to"`
+	Sent    bool      `json:"sent"`
+}
+
+// templateFile is the record digest --export-templates prints for each file
+// it writes.
+type templateFile struct {
+	Path string `json:"path"`
+}
+
+// digestScheduleResult is the record digestschedule prints for --output.
+type digestScheduleResult struct {
+	User       string       `json:"user"`
+	Email      string       `json:"email"`
+	Schedule   string       `json:"schedule"`
+	Timezone   string       `json:"timezone"`
+	LastSentAt sql.NullTime `json:"last_sent_at"`
+	Next       time.Time    `json:"next"`
+}
+
+// digestScheduleRemoved is the record digestschedule off prints for --output.
+type digestScheduleRemoved struct {
+	Removed bool `json:"removed"`
+}
+
+func handlerDigest(s *state, cmd command) error {
+	if dir := cmd.String("export-templates"); dir != "" {
+		paths, err := digest.ExportTemplates(dir)
+		if s.Output != output.Text {
+			files := make([]templateFile, len(paths))
+			for i, path := range paths {
+				files[i] = templateFile{Path: path}
+			}
+			if werr := output.Write(os.Stdout, s.Output, files); err == nil {
+				err = werr
+			}
+			return err
+		}
+		for _, path := range paths {
+			fmt.Printf("Wrote %s\n", path)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Edit them, then set \"digest_templates\": %q in ~/.gatorcli.json or pass --templates.\n", dir)
+		return nil
+	}
+
+	ctx := context.Background()
+	user, err := lookupUser(ctx, s, cmd.String("user"))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	since, err := parseSince(cmd.String("since"), now)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := s.DB.GetDigestSchedule(ctx, user.ID)
+	hasSchedule := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to fetch digest schedule: %w", err)
+	}
+	loc := time.Local
+	if hasSchedule {
+		if loc, err = time.LoadLocation(schedule.Timezone); err != nil {
+			loc = time.Local
+		}
+	}
+
+	templates, err := digest.LoadTemplates(digestTemplateDir(s, cmd.String("templates")))
+	if err != nil {
+		return err
+	}
+	d, err := buildDigest(ctx, s.DB, user, since, now, loc)
+	if err != nil {
+		return err
+	}
+	subject, text, html, err := templates.Render(d)
+	if err != nil {
+		return err
+	}
+
+	result := digestResult{User: user.Name, Since: d.Since, Until: d.Until, Posts: d.Count, Subject: subject}
+	if !cmd.Bool("send") {
+		if s.Output != output.Text {
+			return output.Write(os.Stdout, s.Output, result)
+		}
+		if cmd.Bool("html") {
+			fmt.Print(html)
+		} else {
+			fmt.Printf("Subject: %s\n\n%s", subject, text)
+		}
+		return nil
+	}
+
+	to := cmd.String("to")
+	if to == "" && hasSchedule {
+		to = schedule.Email
+	}
+	if to == "" {
+		return errors.New("no recipient: pass --to, or set an address with 'gator digestschedule'")
+	}
+	result.To = to
+	if d.Count == 0 {
+		if s.Output != output.Text {
+			return output.Write(os.Stdout, s.Output, result)
+		}
+		fmt.Printf("No unread posts since %s; nothing sent.\n", d.Since.Format("2006-01-02 15:04"))
+		return nil
+	}
+	if err := sendDigest(s, to, subject, text, html); err != nil {
+		return err
+	}
+	if s.Output != output.Text {
+		result.Sent = true
+		return output.Write(os.Stdout, s.Output, result)
+	}
+	fmt.Printf("Sent a digest of %d posts to %s.\n", d.Count, to)
+	return nil
+}
+
+// handlerDigestSchedule shows, sets or removes the schedule on which the
+// aggregator mails the user a digest.
+func handlerDigestSchedule(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	existing, err := s.DB.GetDigestSchedule(ctx, user.ID)
+	hasSchedule := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to fetch digest schedule: %w", err)
+	}
+
+	if len(cmd.Args) == 0 {
+		if !hasSchedule && s.Output != output.Text {
+			return output.Write(os.Stdout, s.Output, []digestScheduleResult{})
+		}
+		if !hasSchedule {
+			fmt.Println("You have no digest schedule. Use 'gator digestschedule daily at 07:00 --to you@example.com' to set one.")
+			return nil
+		}
+		return printDigestSchedule(s, user, existing)
+	}
+
+	spec := strings.Join(cmd.Args, " ")
+	if spec == "off" {
+		removed, err := s.DB.DeleteDigestSchedule(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to remove digest schedule: %w", err)
+		}
+		if s.Output != output.Text {
+			return output.Write(os.Stdout, s.Output, digestScheduleRemoved{Removed: removed > 0})
+		}
+		if removed == 0 {
+			fmt.Println("You have no digest schedule.")
+			return nil
+		}
+		fmt.Println("Digest schedule removed.")
+		return nil
+	}
+
+	schedule, err := digest.ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	tz := cmd.String("tz")
+	if tz == "" && hasSchedule {
+		tz = existing.Timezone
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return fmt.Errorf("unknown timezone '%s': use an IANA name such as Europe/Berlin", tz)
+	}
+	to := cmd.String("to")
+	if to == "" && hasSchedule {
+		to = existing.Email
+	}
+	if to == "" {
+		return errors.New("--to is required: the address to send digests to")
+	}
+	if _, err := mail.ParseAddress(to); err != nil {
+		return fmt.Errorf("invalid email address '%s'", to)
+	}
+
+	saved, err := s.DB.SetDigestSchedule(ctx, database.SetDigestScheduleParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+		Email:     to,
+		Schedule:  schedule.String(),
+		Timezone:  loc.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save digest schedule: %w", err)
+	}
+	return printDigestSchedule(s, user, saved)
+}
+
+func printDigestSchedule(s *state, user database.User, row database.DigestSchedule) error {
+	schedule, loc, next, err := digestTiming(row.Schedule, row.Timezone, lastDigest(row.LastSentAt, row.CreatedAt))
+	if err != nil {
+		return err
+	}
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, digestScheduleResult{
+			User:       user.Name,
+			Email:      row.Email,
+			Schedule:   schedule.String(),
+			Timezone:   loc.String(),
+			LastSentAt: row.LastSentAt,
+			Next:       next,
+		})
+	}
+	fmt.Printf("Digest for %s: %s (%s) to %s.\n", user.Name, schedule, loc, row.Email)
+	if row.LastSentAt.Valid {
+		fmt.Printf("  Last sent: %s\n", row.LastSentAt.Time.In(loc).Format("Mon Jan 2 15:04 MST"))
+	}
+	fmt.Printf("  Next:      %s\n", next.Format("Mon Jan 2 15:04 MST"))
+	if s.Config.SMTP == nil {
+		fmt.Println(`Note: 'gator agg' sends digests only once an "smtp" section is added to ~/.gatorcli.json.`)
+	}
+	return nil
+}
+
+// lastDigest is when the previous digest went out, or when the schedule was
+// made if none has yet.
+func lastDigest(sentAt sql.NullTime, createdAt time.Time) time.Time {
+	if sentAt.Valid {
+		return sentAt.Time
+	}
+	return createdAt
+}
+
+// digestTiming reads a stored schedule and returns when it next falls after
+// last.
+func digestTiming(spec, tz string, last time.Time) (digest.Schedule, *time.Location, time.Time, error) {
+	schedule, err := digest.ParseSchedule(spec)
+	if err != nil {
+		return digest.Schedule{}, nil, time.Time{}, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return digest.Schedule{}, nil, time.Time{}, fmt.Errorf("unknown timezone '%s': %w", tz, err)
+	}
+	return schedule, loc, schedule.Next(last, loc), nil
+}
+
+// digestTemplateDir returns the template directory given by flag, or the one
+// in the config, with a leading ~ expanded.
+func digestTemplateDir(s *state, flag string) string {
+	dir := flag
+	if dir == "" {
+		dir = s.Config.DigestTemplates
+	}
+	if expanded, err := homedir.Expand(dir); err == nil {
+		return expanded
+	}
+	return dir
+}
+
+// buildDigest collects the unread posts a user's feeds delivered since a time
+// into a digest, one post per story. Posts are picked by when they were
+// fetched rather than their publication date, so one that turns up with an
+// older date is still mailed once instead of falling between two digests.
+func buildDigest(ctx context.Context, q *database.Queries, user database.User, since, until time.Time, loc *time.Location) (digest.Digest, error) {
+	posts, err := q.GetPostsForUser(ctx, database.GetPostsForUserParams{
+		UserID:          user.ID,
+		CreatedSince:    sql.NullTime{Time: since, Valid: true},
+		CollapseStories: true,
+		Lim:             maxDigestPosts,
+	})
+	if err != nil {
+		return digest.Digest{}, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	entries := make([]digest.Entry, len(posts))
+	for i, post := range posts {
+		entries[i] = digest.Entry{
+			Category: post.Category.String,
+			FeedName: post.FeedName,
+			FeedURL:  post.FeedUrl,
+			Post: digest.Post{
+				Seq:         post.Seq,
+				Title:       post.Title,
+				URL:         post.Url,
+				Author:      post.Author.String,
+				Summary:     feed.Excerpt(postBody(post.Description, post.Content), digestSummaryLength),
+				PublishedAt: post.PublishedAt,
+				AlsoIn:      post.AlsoIn,
+			},
+		}
+	}
+	return digest.Build(user.Name, since, until, loc, entries), nil
+}
+
+func sendDigest(s *state, to, subject, text, html string) error {
+	if s.Config.SMTP == nil {
+		return errNoSMTP
+	}
+	err := mailer.Send(*s.Config.SMTP, mailer.Message{
+		To:      []string{to},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send digest to %s: %w", to, err)
+	}
+	return nil
+}
+
+// sendDueDigests mails every scheduled digest whose time has come. A digest
+// covers the posts fetched since the previous one; one missed while the
+// aggregator was down goes out late rather than not at all. Claiming it first
+// keeps two aggregators from both sending it.
+func sendDueDigests(ctx context.Context, s *state, progress io.Writer) {
+	if s.Config.SMTP == nil {
+		return
+	}
+	schedules, err := s.DB.ListDigestSchedules(ctx)
+	if err != nil {
+		log.Printf("Error fetching digest schedules: %v", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, row := range schedules {
+		schedule, loc, next, err := digestTiming(row.Schedule, row.Timezone, lastDigest(row.LastSentAt, row.CreatedAt))
+		if err != nil {
+			log.Printf("Error reading digest schedule for %s: %v", row.UserName, err)
+			continue
+		}
+		if next.After(now) {
+			continue
+		}
+
+		claimed, err := s.DB.ClaimDigest(ctx, database.ClaimDigestParams{
+			SentAt:     now,
+			UserID:     row.UserID,
+			PrevSentAt: row.LastSentAt,
+		})
+		if err != nil {
+			log.Printf("Error claiming digest for %s: %v", row.UserName, err)
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		since := now.Add(-schedule.Period())
+		if row.LastSentAt.Valid {
+			since = row.LastSentAt.Time
+		}
+		if err := sendScheduledDigest(ctx, s, row, since, now, loc, progress); err != nil {
+			log.Printf("Error sending digest for %s: %v", row.UserName, err)
+			err := s.DB.ResetDigestSent(ctx, database.ResetDigestSentParams{
+				LastSentAt: row.LastSentAt,
+				UserID:     row.UserID,
+			})
+			if err != nil {
+				log.Printf("Error resetting digest for %s: %v", row.UserName, err)
+			}
+		}
+	}
+}
+
+func sendScheduledDigest(ctx context.Context, s *state, row database.ListDigestSchedulesRow, since, until time.Time, loc *time.Location, progress io.Writer) error {
+	templates, err := digest.LoadTemplates(digestTemplateDir(s, ""))
+	if err != nil {
+		return err
+	}
+	user := database.User{ID: row.UserID, Name: row.UserName}
+	d, err := buildDigest(ctx, s.DB, user, since, until, loc)
+	if err != nil {
+		return err
+	}
+	if d.Count == 0 {
+		fmt.Fprintf(progress, "   Digest for %s: no unread posts, nothing sent.\n", row.UserName)
+		return nil
+	}
+	subject, text, html, err := templates.Render(d)
+	if err != nil {
+		return err
+	}
+	if err := sendDigest(s, row.Email, subject, text, html); err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "   Sent digest of %d posts to %s (%s).\n", d.Count, row.Email, row.UserName)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/opml"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/term"
+)
+
+func handlerOPMLImport(s *state, cmd command, user database.User) error {
+	return importOPML(s, user, cmd.Args[0])
+}
+
+func handlerOPMLExport(s *state, cmd command, user database.User) error {
+	path := ""
+	if len(cmd.Args) == 1 {
+		path = cmd.Args[0]
+	}
+	return exportOPML(s, user, path)
+}
+
+// opmlResult is the record opml import, and opml export to a file, print
+// for --output.
+type opmlResult struct {
+	File     string `json:"file"`
+	Feeds    int    `json:"feeds"`
+	Imported int    `json:"imported,omitempty"`
+}
+
+// importOPML follows every feed in the file, creating feeds that do not exist
+// yet and filing each follow under the folder it was found in. Feeds that are
+// already followed have their category updated to match the file.
+func importOPML(s *state, user database.User, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open OPML file: %w", err)
+	}
+	defer f.Close()
+
+	subs, err := opml.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	imported := 0
+	for _, sub := range subs {
+		now := time.Now().UTC()
+		err := s.withTx(ctx, func(q *database.Queries) error {
+			feed, err := getFeedByURL(ctx, q, s.URLs, sub.URL)
+			if errors.Is(err, ErrFeedNotFound) {
+				name := term.StripControl(sub.Title)
+				if name == "" {
+					name = sub.URL
+				}
+				feedURL := sub.URL
+				if canonical, err := s.URLs.Canonicalize(sub.URL); err == nil {
+					feedURL = canonical
+				}
+				feed, err = q.CreateFeed(ctx, database.CreateFeedParams{
+					ID:        uuid.New(),
+					CreatedAt: now,
+					UpdatedAt: now,
+					Name:      name,
+					Url:       feedURL,
+					UserID:    user.ID,
+				})
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = q.GetFeedFollowForUserAndFeed(ctx, database.GetFeedFollowForUserAndFeedParams{
+				UserID: user.ID,
+				FeedID: feed.ID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				_, err = createFollow(ctx, q, user.ID, feed.ID, categoryParam(sub.Category), now)
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("failed to look up feed follow: %w", err)
+			}
+
+			_, err = q.SetFeedFollowCategory(ctx, database.SetFeedFollowCategoryParams{
+				Category:  categoryParam(sub.Category),
+				UpdatedAt: now,
+				UserID:    user.ID,
+				FeedID:    feed.ID,
+			})
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %s: %v\n", sub.URL, err)
+			continue
+		}
+		imported++
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, opmlResult{File: path, Feeds: len(subs), Imported: imported})
+	}
+	fmt.Printf("Imported %d of %d feeds from %s.\n", imported, len(subs), path)
+	return nil
+}
+
+// exportOPML writes the user's follows, grouped by category, to path or to
+// stdout when path is empty. With --output and no path the subscriptions are
+// written as records instead of OPML.
+func exportOPML(s *state, user database.User, path string) error {
+	follows, err := s.DB.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed follows: %w", err)
+	}
+
+	subs := make([]opml.Subscription, 0, len(follows))
+	for _, follow := range follows {
+		subs = append(subs, opml.Subscription{
+			Title:    follow.FeedName,
+			URL:      follow.FeedUrl,
+			Category: follow.Category.String,
+		})
+	}
+
+	if path == "" && s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, subs)
+	}
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create OPML file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := opml.Write(w, fmt.Sprintf("gator subscriptions for %s", user.Name), subs); err != nil {
+		return err
+	}
+	switch {
+	case path == "":
+	case s.Output != output.Text:
+		return output.Write(os.Stdout, s.Output, opmlResult{File: path, Feeds: len(subs)})
+	default:
+		fmt.Printf("Exported %d feeds to %s.\n", len(subs), path)
+	}
+	return nil
+}
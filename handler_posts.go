@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/feed"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/render"
+	"github.com/Numpkens/gatorcli/internal/term"
+)
+
+const defaultBrowseLimit = 10
+
+// maxReadWidth caps the line length of rendered posts, even on wide
+// terminals.
+const maxReadWidth = 100
+
+// postRange is an inclusive range of post sequence numbers.
+type postRange struct {
+	From int64
+	To   int64
+}
+
+// resolvePostRange turns a post reference into a range of sequence numbers.
+// A reference is a post UUID, a sequence number ("42") or a range ("40-45").
+func resolvePostRange(ctx context.Context, q *database.Queries, ref string) (postRange, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		post, err := q.GetPostByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return postRange{}, fmt.Errorf("%w: %s", ErrPostNotFound, ref)
+			}
+			return postRange{}, fmt.Errorf("failed to look up post: %w", err)
+		}
+		return postRange{From: post.Seq, To: post.Seq}, nil
+	}
+
+	fromStr, toStr, isRange := strings.Cut(ref, "-")
+	from, err := strconv.ParseInt(fromStr, 10, 64)
+	if err != nil {
+		return postRange{}, fmt.Errorf("%w '%s': expected a post ID, number or range like 40-45", ErrInvalidPostRef, ref)
+	}
+	if !isRange {
+		return postRange{From: from, To: from}, nil
+	}
+
+	to, err := strconv.ParseInt(toStr, 10, 64)
+	if err != nil || to < from {
+		return postRange{}, fmt.Errorf("%w '%s': expected <from>-<to> with from <= to", ErrInvalidPostRef, ref)
+	}
+	return postRange{From: from, To: to}, nil
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments in order.
+// Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+		}
+		remaining := fs.Args()
+		if consumed := args[:len(args)-len(remaining)]; len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			return append(positional, remaining...), nil
+		}
+		args = remaining
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseDate accepts a calendar date (2006-01-02) or a full RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s': use YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
+
+// parseSince accepts an age such as 12h, 30d or 2w, counted back from now,
+// or anything parseDate does.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if n := len(value) - 1; n > 0 && (value[n] == 'd' || value[n] == 'w') {
+		if count, err := strconv.Atoi(value[:n]); err == nil && count >= 0 {
+			days := count
+			if value[n] == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	t, err := parseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s': use an age like 24h, 30d or 2w, or a date", value)
+	}
+	return t, nil
+}
+
+// languageParam normalizes a --lang flag value into a nullable language code.
+func languageParam(value string) (sql.NullString, error) {
+	if value == "" {
+		return sql.NullString{}, nil
+	}
+	lang := feed.NormalizeLanguage(value)
+	if lang == "" {
+		return sql.NullString{}, fmt.Errorf("invalid language '%s': use a code such as en, de or ja", value)
+	}
+	return sql.NullString{String: lang, Valid: true}, nil
+}
+
+func handlerBrowse(s *state, cmd command, user database.User) error {
+	all := cmd.Bool("all")
+	tag := cmd.String("tag")
+	langParam, err := languageParam(cmd.String("lang"))
+	if err != nil {
+		return err
+	}
+
+	limit := defaultBrowseLimit
+	if len(cmd.Args) == 1 {
+		limit, err = strconv.Atoi(cmd.Args[0])
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid limit '%s': must be a positive integer", cmd.Args[0])
+		}
+	}
+
+	posts, err := s.DB.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: all,
+		Tag:         sql.NullString{String: strings.ToLower(tag), Valid: tag != ""},
+		Category:    categoryParam(cmd.String("category")),
+		Lang:        langParam,
+		// A story syndicated by several followed feeds is listed once.
+		CollapseStories: true,
+		Lim:             int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, posts)
+	}
+
+	if len(posts) == 0 {
+		if all {
+			fmt.Println("No posts found. Run 'gator agg' to collect posts from the feeds you follow.")
+		} else {
+			fmt.Println("No unread posts. Use 'gator browse --all' to include read posts.")
+		}
+		return nil
+	}
+
+	for _, post := range posts {
+		marker := " "
+		if !post.IsRead {
+			marker = "*"
+		}
+		fmt.Printf("%s [%d] %s\n", marker, post.Seq, post.Title)
+		fmt.Printf("      %s | %s\n", post.FeedName, post.PublishedAt.Format("2006-01-02 15:04"))
+		fmt.Printf("      %s\n", post.Url)
+		if len(post.AlsoIn) > 0 {
+			fmt.Printf("      also in: %s\n", strings.Join(post.AlsoIn, ", "))
+		}
+	}
+	return nil
+}
+
+// forEachPostRange resolves each post reference and calls fn with the
+// resulting range, returning the total number of rows fn reports as affected.
+// postCount is the record printed for --output by commands that change a
+// number of posts, such as star or markallread.
+type postCount struct {
+	Posts int64 `json:"posts"`
+}
+
+// printPostCount prints n as a postCount record, or as text built from
+// format when --output is text.
+func printPostCount(s *state, n int64, format string) error {
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, postCount{Posts: n})
+	}
+	fmt.Printf(format, n)
+	return nil
+}
+
+func forEachPostRange(ctx context.Context, q *database.Queries, refs []string, fn func(r postRange) (int64, error)) (int64, error) {
+	var total int64
+	for _, ref := range refs {
+		r, err := resolvePostRange(ctx, q, ref)
+		if err != nil {
+			return total, err
+		}
+		n, err := fn(r)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// A single post is shown as well as marked read; ranges are only marked.
+	if len(cmd.Args) == 1 && !cmd.Bool("mark-only") {
+		r, err := resolvePostRange(ctx, s.DB, cmd.Args[0])
+		if err != nil {
+			return err
+		}
+		if r.From == r.To {
+			return readPost(ctx, s, user, r.From, now)
+		}
+	}
+	marked, err := forEachPostRange(ctx, s.DB, cmd.Args, func(r postRange) (int64, error) {
+		n, err := s.DB.MarkPostsRead(ctx, database.MarkPostsReadParams{
+			UserID:  user.ID,
+			ReadAt:  now,
+			FromSeq: r.From,
+			ToSeq:   r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark posts as read: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostCount(s, marked, "Marked %d posts as read.\n")
+}
+
+// readPost prints one post rendered for the terminal and marks it read.
+func readPost(ctx context.Context, s *state, user database.User, seq int64, now time.Time) error {
+	post, err := s.DB.GetPostBySeq(ctx, seq)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", ErrPostNotFound, seq)
+		}
+		return fmt.Errorf("failed to look up post: %w", err)
+	}
+
+	if s.Output != output.Text {
+		err = output.Write(os.Stdout, s.Output, post)
+	} else {
+		err = printPost(os.Stdout, post)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.MarkPostsRead(ctx, database.MarkPostsReadParams{
+		UserID:  user.ID,
+		ReadAt:  now,
+		FromSeq: seq,
+		ToSeq:   seq,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post as read: %w", err)
+	}
+	return nil
+}
+
+// printPost writes a post's title, source and content, wrapped to the
+// terminal's width. Everything in it comes from the feed, so control
+// characters are stripped before it reaches the terminal.
+func printPost(w io.Writer, post database.GetPostBySeqRow) error {
+	width := 80
+	if term.IsTerminal(os.Stdout) {
+		width, _ = term.Size(os.Stdout)
+		width = min(width, maxReadWidth)
+	}
+
+	var b strings.Builder
+	for _, line := range term.Wrap(post.Title, width) {
+		fmt.Fprintln(&b, line)
+	}
+	fmt.Fprintln(&b, postMeta(post.FeedName, post.Author, post.PublishedAt))
+	fmt.Fprintln(&b, post.Url)
+	if post.CommentsUrl.Valid {
+		fmt.Fprintf(&b, "Comments: %s\n", post.CommentsUrl.String)
+	}
+	if body := render.Text(postBody(post.Description, post.Content), width); body != "" {
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+	_, err := io.WriteString(w, term.StripControl(b.String()))
+	return err
+}
+
+// postMeta formats the line under a post's title: its feed, author and
+// publication time.
+func postMeta(feedName string, author sql.NullString, publishedAt time.Time) string {
+	meta := feedName
+	if author.Valid {
+		meta += " | " + author.String
+	}
+	return meta + " | " + publishedAt.Format("2006-01-02 15:04")
+}
+
+// postBody returns the richer of a post's summary and full content, matching
+// gator_post_body in the schema.
+func postBody(description, content sql.NullString) string {
+	if len(content.String) > len(description.String) {
+		return content.String
+	}
+	return description.String
+}
+
+func handlerUnread(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	marked, err := forEachPostRange(ctx, s.DB, cmd.Args, func(r postRange) (int64, error) {
+		n, err := s.DB.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{
+			UserID:  user.ID,
+			FromSeq: r.From,
+			ToSeq:   r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark posts as unread: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostCount(s, marked, "Marked %d posts as unread.\n")
+}
+
+func handlerStar(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	starred, err := forEachPostRange(ctx, s.DB, cmd.Args, func(r postRange) (int64, error) {
+		n, err := s.DB.StarPosts(ctx, database.StarPostsParams{
+			UserID:    user.ID,
+			StarredAt: now,
+			FromSeq:   r.From,
+			ToSeq:     r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to star posts: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostCount(s, starred, "Starred %d posts.\n")
+}
+
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	unstarred, err := forEachPostRange(ctx, s.DB, cmd.Args, func(r postRange) (int64, error) {
+		n, err := s.DB.UnstarPosts(ctx, database.UnstarPostsParams{
+			UserID:  user.ID,
+			FromSeq: r.From,
+			ToSeq:   r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to unstar posts: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostCount(s, unstarred, "Unstarred %d posts.\n")
+}
+
+func handlerStarred(s *state, cmd command, user database.User) error {
+	posts, err := s.DB.GetStarredPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch starred posts: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, posts)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("You have no starred posts. Use 'gator star <post>' to save one.")
+		return nil
+	}
+
+	fmt.Printf("You have %d starred posts:\n", len(posts))
+	for _, post := range posts {
+		fmt.Printf("  [%d] %s\n", post.Seq, post.Title)
+		fmt.Printf("      %s | starred %s\n", post.FeedName, post.StarredAt.Format("2006-01-02 15:04"))
+		fmt.Printf("      %s\n", post.Url)
+	}
+	return nil
+}
+
+func handlerMarkAllRead(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+	}
+	if feedURL := cmd.String("feed"); feedURL != "" {
+		feed, err := getFeedByURL(ctx, s.DB, s.URLs, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if before := cmd.String("before"); before != "" {
+		t, err := parseDate(before)
+		if err != nil {
+			return err
+		}
+		params.Before = sql.NullTime{Time: t, Valid: true}
+	}
+
+	marked, err := s.DB.MarkAllPostsRead(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to mark posts as read: %w", err)
+	}
+
+	return printPostCount(s, marked, "Marked %d posts as read.\n")
+}
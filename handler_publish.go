@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/publish"
+)
+
+const defaultPublishLimit = 50
+
+// publishFilter selects the posts in a published feed. Link is the URL the
+// document will be served from.
+type publishFilter struct {
+	Tag      string
+	Category string
+	FeedURL  string
+	Lang     string
+	Since    sql.NullTime
+	Unread   bool
+	Limit    int
+	Link     string
+}
+
+// describe returns a short human-readable summary of the filter, which also
+// seeds the feed's stable ID.
+func (f publishFilter) describe() string {
+	var parts []string
+	if f.Category != "" {
+		parts = append(parts, "category "+f.Category)
+	}
+	if f.Tag != "" {
+		parts = append(parts, "tag "+strings.ToLower(f.Tag))
+	}
+	if f.FeedURL != "" {
+		parts = append(parts, "feed "+f.FeedURL)
+	}
+	if f.Lang != "" {
+		parts = append(parts, "language "+f.Lang)
+	}
+	if f.Unread {
+		parts = append(parts, "unread")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildPublishedFeed collects a user's timeline, narrowed by filter, into a
+// feed document. The feed's ID depends only on the user and the filter, so
+// republishing the same selection keeps its identity.
+func buildPublishedFeed(ctx context.Context, s *state, user database.User, filter publishFilter) (publish.Feed, error) {
+	lang, err := languageParam(filter.Lang)
+	if err != nil {
+		return publish.Feed{}, err
+	}
+	params := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: !filter.Unread,
+		Tag:         sql.NullString{String: strings.ToLower(filter.Tag), Valid: filter.Tag != ""},
+		Category:    categoryParam(filter.Category),
+		Lang:        lang,
+		Since:       filter.Since,
+		Lim:         int32(filter.Limit),
+	}
+	if filter.FeedURL != "" {
+		feed, err := getFeedByURL(ctx, s.DB, s.URLs, filter.FeedURL)
+		if err != nil {
+			return publish.Feed{}, err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	posts, err := s.DB.GetPostsForUser(ctx, params)
+	if err != nil {
+		return publish.Feed{}, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	title := fmt.Sprintf("%s's reading", user.Name)
+	if desc := filter.describe(); desc != "" {
+		title += " (" + desc + ")"
+	}
+	out := publish.Feed{
+		ID:      "urn:uuid:" + uuid.NewSHA1(user.ID, []byte(filter.describe())).String(),
+		Title:   title,
+		Link:    filter.Link,
+		Author:  user.Name,
+		Updated: user.CreatedAt,
+	}
+	for _, post := range posts {
+		if post.UpdatedAt.After(out.Updated) {
+			out.Updated = post.UpdatedAt
+		}
+		out.Entries = append(out.Entries, publish.Entry{
+			ID:        "urn:uuid:" + post.ID.String(),
+			Title:     post.Title,
+			URL:       post.Url,
+			Summary:   postBody(post.Description, post.Content),
+			Published: post.PublishedAt,
+			Updated:   post.UpdatedAt,
+			Source:    post.FeedName,
+			SourceURL: post.FeedUrl,
+		})
+	}
+	return out, nil
+}
+
+// publishResult is the record publish --out prints for --output. Without
+// --out the entries themselves are written as records instead of a feed.
+type publishResult struct {
+	File    string    `json:"file"`
+	Format  string    `json:"format"`
+	Posts   int       `json:"posts"`
+	Updated time.Time `json:"updated"`
+}
+
+func handlerPublish(s *state, cmd command) error {
+	out := cmd.String("out")
+	format := cmd.String("format")
+	limit := cmd.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("invalid limit %d: must be a positive integer", limit)
+	}
+	if format != publish.FormatAtom && format != publish.FormatRSS {
+		return fmt.Errorf("unknown format '%s': expected atom or rss", format)
+	}
+	if format == publish.FormatRSS && cmd.String("link") == "" {
+		return errors.New("--link is required for rss output: RSS channels must link to where they are published")
+	}
+
+	ctx := context.Background()
+	user, err := lookupUser(ctx, s, cmd.String("user"))
+	if err != nil {
+		return err
+	}
+
+	filter := publishFilter{
+		Tag:      cmd.String("tag"),
+		Category: cmd.String("category"),
+		FeedURL:  cmd.String("feed"),
+		Lang:     cmd.String("lang"),
+		Unread:   cmd.Bool("unread"),
+		Limit:    limit,
+		Link:     cmd.String("link"),
+	}
+	if since := cmd.String("since"); since != "" {
+		t, err := parseDate(since)
+		if err != nil {
+			return err
+		}
+		filter.Since = sql.NullTime{Time: t, Valid: true}
+	}
+
+	doc, err := buildPublishedFeed(ctx, s, user, filter)
+	if err != nil {
+		return err
+	}
+
+	if out == "" && s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, doc.Entries)
+	}
+	if out == "" {
+		return publish.Write(os.Stdout, format, doc)
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := publish.Write(f, format, doc); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, publishResult{
+			File:    out,
+			Format:  format,
+			Posts:   len(doc.Entries),
+			Updated: doc.Updated,
+		})
+	}
+	fmt.Printf("Published %d posts from %s's timeline to %s (%s, updated %s).\n",
+		len(doc.Entries), user.Name, out, format, doc.Updated.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/rules"
+)
+
+func handlerRuleAdd(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	name := strings.TrimSpace(cmd.Args[0])
+	if name == "" {
+		return errors.New("rule names cannot be empty")
+	}
+
+	action := cmd.String("action")
+	if !rules.ValidAction(action) {
+		return fmt.Errorf("invalid action '%s': expected %s", action, strings.Join(rules.Actions, ", "))
+	}
+	var tag sql.NullString
+	if action == rules.ActionTag {
+		tags, err := normalizeTags([]string{cmd.String("tag")})
+		if err != nil {
+			return errors.New("the tag action needs --tag")
+		}
+		tag = sql.NullString{String: tags[0], Valid: true}
+	} else if cmd.String("tag") != "" {
+		return errors.New("--tag only applies to the tag action")
+	}
+
+	lang, err := languageParam(cmd.String("lang"))
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	params := database.CreateRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Name:      name,
+		Scope:     cmd.String("in"),
+		Pattern:   sql.NullString{String: cmd.String("match"), Valid: cmd.String("match") != ""},
+		Keywords:  rules.ParseKeywords(cmd.String("keywords")),
+		Author:    sql.NullString{String: cmd.String("author"), Valid: cmd.String("author") != ""},
+		Category:  categoryParam(cmd.String("category")),
+		Language:  lang,
+		Action:    action,
+		Tag:       tag,
+	}
+	if params.Keywords == nil {
+		params.Keywords = []string{}
+	}
+	var feedURL sql.NullString
+	if cmd.String("feed") != "" {
+		f, err := getFeedByURL(ctx, s.DB, s.URLs, cmd.String("feed"))
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: f.ID, Valid: true}
+		feedURL = sql.NullString{String: f.Url, Valid: true}
+	}
+
+	rule := database.Rule{
+		Scope:    params.Scope,
+		Pattern:  params.Pattern,
+		Keywords: params.Keywords,
+		FeedID:   params.FeedID,
+		Author:   params.Author,
+		Category: params.Category,
+		Language: params.Language,
+	}
+	if _, err := ruleMatcher(rule); err != nil {
+		return err
+	}
+
+	created, err := s.DB.CreateRule(ctx, params)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("you already have a rule named '%s'", name)
+		}
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, created)
+	}
+	fmt.Printf("Added rule '%s': %s.\n", created.Name, describeRule(created, feedURL))
+	return nil
+}
+
+func handlerRuleList(s *state, cmd command, user database.User) error {
+	list, err := s.DB.ListRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch rules: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, list)
+	}
+
+	if len(list) == 0 {
+		fmt.Println("You have no rules. Use 'gator rule add <name> --action <action>' to make one.")
+		return nil
+	}
+
+	fmt.Printf("You have %d rules:\n", len(list))
+	for _, r := range list {
+		fmt.Printf("  - %s: %s\n", r.Rule.Name, describeRule(r.Rule, r.FeedUrl))
+	}
+	return nil
+}
+
+// ruleRemoved is the record rule remove prints for --output.
+type ruleRemoved struct {
+	Name string `json:"name"`
+}
+
+func handlerRuleRemove(s *state, cmd command, user database.User) error {
+	name := cmd.Args[0]
+	removed, err := s.DB.DeleteRule(context.Background(), database.DeleteRuleParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove rule: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, ruleRemoved{Name: name})
+	}
+	fmt.Printf("Removed rule '%s'.\n", name)
+	return nil
+}
+
+// defaultRuleSince is how far back rule test and rule apply look when no
+// posts or --since are given.
+const defaultRuleSince = "30d"
+
+// ruleMatch is a post a rule matched, the record rule test and rule apply
+// print for --output.
+type ruleMatch struct {
+	Rule        string         `json:"rule"`
+	Seq         int64          `json:"seq"`
+	Title       string         `json:"title"`
+	FeedName    string         `json:"feed_name"`
+	Author      sql.NullString `json:"author"`
+	PublishedAt time.Time      `json:"published_at"`
+}
+
+// newRuleMatch records that rule matched post.
+func newRuleMatch(rule database.Rule, post database.GetFollowedPostsSinceRow) ruleMatch {
+	return ruleMatch{
+		Rule:        rule.Name,
+		Seq:         post.Seq,
+		Title:       post.Title,
+		FeedName:    post.FeedName,
+		Author:      post.Author,
+		PublishedAt: post.PublishedAt,
+	}
+}
+
+// handlerRuleTest reports which posts a rule would act on, without acting on
+// them: the posts given, or else every post from followed feeds published
+// since --since.
+func handlerRuleTest(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	rule, err := getRule(ctx, s.DB, user.ID, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	m, err := ruleMatcher(rule.Rule)
+	if err != nil {
+		return fmt.Errorf("rule '%s' is invalid: %w", rule.Rule.Name, err)
+	}
+	if s.Output == output.Text {
+		fmt.Printf("Rule '%s': %s.\n", rule.Rule.Name, describeRule(rule.Rule, rule.FeedUrl))
+	}
+
+	if len(cmd.Args) > 1 {
+		return testRuleOnPosts(ctx, s, user, rule.Rule, m, cmd.Args[1:])
+	}
+
+	since, err := parseSince(cmd.String("since"), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	posts, err := s.DB.GetFollowedPostsSince(ctx, database.GetFollowedPostsSinceParams{UserID: user.ID, Since: since})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	matched := matchingPosts(m, posts)
+	if s.Output != output.Text {
+		records := make([]ruleMatch, 0, len(matched))
+		for _, post := range matched {
+			records = append(records, newRuleMatch(rule.Rule, post))
+		}
+		return output.Write(os.Stdout, s.Output, records)
+	}
+	for _, post := range matched {
+		printRuleMatch(post)
+	}
+	fmt.Printf("%d of %d posts since %s would be %s.\n", len(matched), len(posts), since.Format("2006-01-02 15:04"), actionDone(rule.Rule))
+	return nil
+}
+
+// testRuleOnPosts checks a rule against posts given by number, range or ID,
+// marking the ones it matches.
+func testRuleOnPosts(ctx context.Context, s *state, user database.User, rule database.Rule, m *rules.Matcher, refs []string) error {
+	matched, total := []ruleMatch{}, 0
+	categories := map[uuid.UUID]string{}
+	for _, ref := range refs {
+		r, err := resolvePostRange(ctx, s.DB, ref)
+		if err != nil {
+			return err
+		}
+		for seq := r.From; seq <= r.To; seq++ {
+			post, err := s.DB.GetPostBySeq(ctx, seq)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to look up post: %w", err)
+			}
+			category, ok := categories[post.FeedID]
+			if !ok {
+				follow, err := s.DB.GetFeedFollowForUserAndFeed(ctx, database.GetFeedFollowForUserAndFeedParams{
+					UserID: user.ID,
+					FeedID: post.FeedID,
+				})
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("failed to look up follow: %w", err)
+				}
+				category = follow.Category.String
+				categories[post.FeedID] = category
+			}
+
+			total++
+			mark := " "
+			if m.Match(rules.Post{
+				Title:    post.Title,
+				Body:     postBody(post.Description, post.Content),
+				FeedID:   post.FeedID,
+				Author:   post.Author.String,
+				Category: category,
+				Language: post.Language.String,
+			}) {
+				mark = "*"
+				matched = append(matched, ruleMatch{
+					Rule:        rule.Name,
+					Seq:         post.Seq,
+					Title:       post.Title,
+					FeedName:    post.FeedName,
+					Author:      post.Author,
+					PublishedAt: post.PublishedAt,
+				})
+			}
+			if s.Output == output.Text {
+				fmt.Printf("  %s %d %s\n", mark, post.Seq, post.Title)
+			}
+		}
+	}
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, matched)
+	}
+	fmt.Printf("%d of %d posts would be %s.\n", len(matched), total, actionDone(rule))
+	return nil
+}
+
+// handlerRuleApply runs a rule, or all of the user's rules, over the posts
+// from followed feeds published since --since, as if they had just arrived.
+func handlerRuleApply(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	since, err := parseSince(cmd.String("since"), now)
+	if err != nil {
+		return err
+	}
+
+	var list []database.Rule
+	if len(cmd.Args) == 1 {
+		rule, err := getRule(ctx, s.DB, user.ID, cmd.Args[0])
+		if err != nil {
+			return err
+		}
+		list = append(list, rule.Rule)
+	} else {
+		rows, err := s.DB.ListRulesForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch rules: %w", err)
+		}
+		for _, row := range rows {
+			list = append(list, row.Rule)
+		}
+		if len(list) == 0 && s.Output != output.Text {
+			return output.Write(os.Stdout, s.Output, []ruleMatch{})
+		}
+		if len(list) == 0 {
+			fmt.Println("You have no rules. Use 'gator rule add <name> --action <action>' to make one.")
+			return nil
+		}
+	}
+
+	posts, err := s.DB.GetFollowedPostsSince(ctx, database.GetFollowedPostsSinceParams{UserID: user.ID, Since: since})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	records := []ruleMatch{}
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		for _, rule := range list {
+			m, err := ruleMatcher(rule)
+			if err != nil {
+				return fmt.Errorf("rule '%s' is invalid: %w", rule.Name, err)
+			}
+			matched := matchingPosts(m, posts)
+			for _, post := range matched {
+				if err := applyRule(ctx, q, rule, post.Seq, now); err != nil {
+					return fmt.Errorf("failed to apply rule '%s' to %q: %w", rule.Name, post.Title, err)
+				}
+				if s.Output != output.Text {
+					records = append(records, newRuleMatch(rule, post))
+				} else if rule.Action == rules.ActionNotify {
+					printRuleMatch(post)
+				}
+			}
+			if s.Output == output.Text {
+				fmt.Printf("Rule '%s': %d of %d posts since %s were %s.\n", rule.Name, len(matched), len(posts), since.Format("2006-01-02 15:04"), actionDone(rule))
+			}
+		}
+		return nil
+	})
+	if err != nil || s.Output == output.Text {
+		return err
+	}
+	return output.Write(os.Stdout, s.Output, records)
+}
+
+// matchingPosts returns the posts a rule matches, in their original order.
+func matchingPosts(m *rules.Matcher, posts []database.GetFollowedPostsSinceRow) []database.GetFollowedPostsSinceRow {
+	var matched []database.GetFollowedPostsSinceRow
+	for _, post := range posts {
+		if m.Match(rules.Post{
+			Title:    post.Title,
+			Body:     postBody(post.Description, post.Content),
+			FeedID:   post.FeedID,
+			Author:   post.Author.String,
+			Category: post.Category.String,
+			Language: post.Language.String,
+		}) {
+			matched = append(matched, post)
+		}
+	}
+	return matched
+}
+
+// printRuleMatch lists a post a rule matched.
+func printRuleMatch(post database.GetFollowedPostsSinceRow) {
+	fmt.Printf("  %d %s\n", post.Seq, post.Title)
+	fmt.Printf("      %s\n", postMeta(post.FeedName, post.Author, post.PublishedAt))
+}
+
+// getRule looks up one of a user's rules by name, translating a missing row
+// into ErrRuleNotFound.
+func getRule(ctx context.Context, q *database.Queries, userID uuid.UUID, name string) (database.GetRuleForUserRow, error) {
+	rule, err := q.GetRuleForUser(ctx, database.GetRuleForUserParams{UserID: userID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return rule, fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+	if err != nil {
+		return rule, fmt.Errorf("failed to look up rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ruleMatcher compiles a stored rule's condition.
+func ruleMatcher(r database.Rule) (*rules.Matcher, error) {
+	return rules.Compile(rules.Condition{
+		Pattern:  r.Pattern.String,
+		Keywords: r.Keywords,
+		Scope:    r.Scope,
+		FeedID:   r.FeedID,
+		Author:   r.Author.String,
+		Category: r.Category.String,
+		Language: r.Language.String,
+	})
+}
+
+// describeRule summarizes a rule's condition and action in words.
+func describeRule(r database.Rule, feedURL sql.NullString) string {
+	var conds []string
+	where := map[string]string{
+		rules.ScopeAny:     "title or content",
+		rules.ScopeTitle:   "title",
+		rules.ScopeContent: "content",
+	}[r.Scope]
+	if r.Pattern.Valid {
+		conds = append(conds, fmt.Sprintf("%s matches /%s/", where, r.Pattern.String))
+	}
+	if len(r.Keywords) > 0 {
+		conds = append(conds, fmt.Sprintf("%s mentions %s", where, strings.Join(r.Keywords, " or ")))
+	}
+	if feedURL.Valid {
+		conds = append(conds, "feed is "+feedURL.String)
+	}
+	if r.Author.Valid {
+		conds = append(conds, fmt.Sprintf("author contains '%s'", r.Author.String))
+	}
+	if r.Category.Valid {
+		conds = append(conds, fmt.Sprintf("category is '%s'", r.Category.String))
+	}
+	if r.Language.Valid {
+		conds = append(conds, "language is "+r.Language.String)
+	}
+	return fmt.Sprintf("when %s, %s", strings.Join(conds, " and "), actionVerb(r))
+}
+
+// actionVerb says what a rule does to a post.
+func actionVerb(r database.Rule) string {
+	switch r.Action {
+	case rules.ActionRead:
+		return "mark it read"
+	case rules.ActionStar:
+		return "star it"
+	case rules.ActionTag:
+		return fmt.Sprintf("tag it '%s'", r.Tag.String)
+	case rules.ActionHide:
+		return "hide it"
+	case rules.ActionNotify:
+		return "notify you"
+	}
+	return r.Action
+}
+
+// actionDone says what a rule has done to the posts it matched.
+func actionDone(r database.Rule) string {
+	switch r.Action {
+	case rules.ActionRead:
+		return "marked read"
+	case rules.ActionStar:
+		return "starred"
+	case rules.ActionTag:
+		return fmt.Sprintf("tagged '%s'", r.Tag.String)
+	case rules.ActionHide:
+		return "hidden"
+	case rules.ActionNotify:
+		return "notified"
+	}
+	return r.Action
+}
+
+// applyRule takes a rule's action on one post for the rule's owner. Hidden
+// posts are marked read too, so they drop out of unread counts. Only the
+// matched post is marked read: another feed's copy of the story did not
+// match and stays unread. Notifying is left to the caller.
+func applyRule(ctx context.Context, q *database.Queries, r database.Rule, seq int64, now time.Time) error {
+	var err error
+	switch r.Action {
+	case rules.ActionRead:
+		_, err = q.MarkPostRead(ctx, database.MarkPostReadParams{UserID: r.UserID, ReadAt: now, Seq: seq})
+	case rules.ActionStar:
+		_, err = q.StarPosts(ctx, database.StarPostsParams{UserID: r.UserID, StarredAt: now, FromSeq: seq, ToSeq: seq})
+	case rules.ActionTag:
+		_, err = q.TagPosts(ctx, database.TagPostsParams{
+			UserID:    r.UserID,
+			CreatedAt: now,
+			Tags:      []string{r.Tag.String},
+			FromSeq:   seq,
+			ToSeq:     seq,
+		})
+	case rules.ActionHide:
+		_, err = q.HidePosts(ctx, database.HidePostsParams{UserID: r.UserID, HiddenAt: now, FromSeq: seq, ToSeq: seq})
+		if err == nil {
+			_, err = q.MarkPostRead(ctx, database.MarkPostReadParams{UserID: r.UserID, ReadAt: now, Seq: seq})
+		}
+	}
+	return err
+}
+
+// feedRule is a rule that applies to a feed being refreshed, compiled once
+// for all of the feed's new posts.
+type feedRule struct {
+	database.GetRulesForFeedRow
+	matcher *rules.Matcher
+}
+
+// loadFeedRules fetches and compiles the rules of everyone who follows a
+// feed. Rules that no longer compile are logged and skipped.
+func loadFeedRules(ctx context.Context, q *database.Queries, feedID uuid.UUID) ([]feedRule, error) {
+	rows, err := q.GetRulesForFeed(ctx, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules: %w", err)
+	}
+	loaded := make([]feedRule, 0, len(rows))
+	for _, row := range rows {
+		m, err := ruleMatcher(row.Rule)
+		if err != nil {
+			log.Printf("Skipping %s's rule '%s': %v", row.UserName, row.Rule.Name, err)
+			continue
+		}
+		loaded = append(loaded, feedRule{GetRulesForFeedRow: row, matcher: m})
+	}
+	return loaded, nil
+}
+
+// runRules applies every matching rule to a newly saved post and returns the
+// IDs of the rules that matched. Notifications are written to progress.
+func runRules(ctx context.Context, q *database.Queries, feedRules []feedRule, post database.UpsertPostRow, now time.Time, progress io.Writer) map[uuid.UUID]bool {
+	matched := map[uuid.UUID]bool{}
+	for _, r := range feedRules {
+		if !r.matcher.Match(rules.Post{
+			Title:    post.Title,
+			Body:     postBody(post.Description, post.Content),
+			FeedID:   post.FeedID,
+			Author:   post.Author.String,
+			Category: r.FollowCategory.String,
+			Language: post.Language.String,
+		}) {
+			continue
+		}
+		matched[r.Rule.ID] = true
+		if err := applyRule(ctx, q, r.Rule, post.Seq, now); err != nil {
+			log.Printf("Error applying %s's rule '%s' to %q: %v", r.UserName, r.Rule.Name, post.Title, err)
+			continue
+		}
+		if r.Rule.Action == rules.ActionNotify {
+			fmt.Fprintf(progress, "   ! %s: %s (rule '%s')\n", r.UserName, post.Title, r.Rule.Name)
+		}
+	}
+	return matched
+}
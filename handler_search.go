@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+const defaultSearchLimit = 20
+
+// Snippet highlight markers emitted by ts_headline in the SearchPosts query.
+const (
+	snippetStartSel = "<<"
+	snippetStopSel  = ">>"
+)
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatSnippet collapses whitespace in a search snippet and renders the
+// highlight markers as bold text on a terminal, or as *word* otherwise.
+func formatSnippet(snippet string, tty bool) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	start, stop := "*", "*"
+	if tty {
+		start, stop = "\033[1m", "\033[0m"
+	}
+	snippet = strings.ReplaceAll(snippet, snippetStartSel, start)
+	return strings.ReplaceAll(snippet, snippetStopSel, stop)
+}
+
+func handlerSearch(s *state, cmd command, user database.User) error {
+	unread, read := cmd.Bool("unread"), cmd.Bool("read")
+	if unread && read {
+		return errors.New("--unread and --read cannot be used together")
+	}
+	limit := cmd.Int("limit")
+	if limit <= 0 {
+		return fmt.Errorf("invalid limit %d: must be a positive integer", limit)
+	}
+	tag := cmd.String("tag")
+	langParam, err := languageParam(cmd.String("lang"))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	params := database.SearchPostsParams{
+		Lang:   langParam,
+		Query:  strings.Join(cmd.Args, " "),
+		UserID: user.ID,
+		Tag:    sql.NullString{String: strings.ToLower(tag), Valid: tag != ""},
+		Lim:    int32(limit),
+	}
+	if feedURL := cmd.String("feed"); feedURL != "" {
+		feed, err := getFeedByURL(ctx, s.DB, s.URLs, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if since := cmd.String("since"); since != "" {
+		t, err := parseDate(since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	if until := cmd.String("until"); until != "" {
+		t, err := parseDate(until)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: t, Valid: true}
+	}
+	if unread || read {
+		params.IsRead = sql.NullBool{Bool: read, Valid: true}
+	}
+
+	results, err := s.DB.SearchPosts(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No posts match %q.\n", params.Query)
+		return nil
+	}
+
+	tty := isTerminal(os.Stdout)
+	fmt.Printf("Found %d posts matching %q:\n", len(results), params.Query)
+	for _, r := range results {
+		marker := " "
+		if !r.IsRead {
+			marker = "*"
+		}
+		fmt.Printf("%s [%d] %s\n", marker, r.Seq, r.Title)
+		fmt.Printf("      %s | %s | rank %.3f\n", r.FeedName, r.PublishedAt.Format("2006-01-02"), r.Rank)
+		fmt.Printf("      %s\n", formatSnippet(r.Snippet, tty))
+		fmt.Printf("      %s\n", r.Url)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/auth"
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/feed"
+)
+
+// apiServer serves the JSON REST API over the same database as the CLI.
+type apiServer struct {
+	state     *state
+	serverKey string
+}
+
+// apiError is an error with the HTTP status it should be reported as.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func badRequest(format string, args ...any) error {
+	return &apiError{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+type apiHandlerFunc func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+func handlerServe(s *state, cmd command) error {
+	addr := cmd.String("addr")
+
+	if s.Config.APIKey == "" {
+		key, err := auth.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := s.Config.SetAPIKey(key); err != nil {
+			return fmt.Errorf("failed to save server API key: %w", err)
+		}
+		fmt.Printf("Generated server API key (saved to config): %s\n", key)
+	}
+
+	api := &apiServer{state: s, serverKey: s.Config.APIKey}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           api.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	fmt.Printf("Serving the gator API on %s. Press Ctrl+C to stop.\n", addr)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	fmt.Println("Server stopped.")
+	return nil
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("GET /api/v1/me", a.authed(a.handleMe))
+	mux.HandleFunc("GET /api/v1/users", a.authed(a.handleListUsers))
+	mux.HandleFunc("GET /api/v1/feeds", a.authed(a.handleListFeeds))
+	mux.HandleFunc("POST /api/v1/feeds", a.authed(a.handleAddFeed))
+	mux.HandleFunc("GET /api/v1/follows", a.authed(a.handleListFollows))
+	mux.HandleFunc("POST /api/v1/follows", a.authed(a.handleFollow))
+	mux.HandleFunc("PATCH /api/v1/follows", a.authed(a.handleMoveFollow))
+	mux.HandleFunc("DELETE /api/v1/follows", a.authed(a.handleUnfollow))
+	mux.HandleFunc("GET /api/v1/posts", a.authed(a.handleListPosts))
+	mux.HandleFunc("GET /api/v1/posts/search", a.authed(a.handleSearchPosts))
+	mux.HandleFunc("POST /api/v1/posts/{ref}/read", a.authed(a.handleMarkRead))
+	mux.HandleFunc("DELETE /api/v1/posts/{ref}/read", a.authed(a.handleMarkUnread))
+	mux.HandleFunc("POST /api/v1/posts/{ref}/star", a.authed(a.handleStar))
+	mux.HandleFunc("DELETE /api/v1/posts/{ref}/star", a.authed(a.handleUnstar))
+	mux.HandleFunc("GET /api/v1/stars", a.authed(a.handleListStars))
+	mux.HandleFunc("GET /api/v1/publish", a.feedAuthed(a.handlePublish))
+
+	a.readerRoutes(mux)
+
+	return logRequests(a.withReaderStreams(mux))
+}
+
+// logRequests logs the method, path, status and duration of every request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// authed resolves the calling user from the request's API key and reports
+// handler errors as JSON.
+func (a *apiServer) authed(handler apiHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := a.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := handler(w, r, user); err != nil {
+			writeError(w, err)
+		}
+	}
+}
+
+// feedAuthed is authed for endpoints that feed readers subscribe to. Many
+// readers cannot send headers, so the key may also be passed as a key query
+// parameter.
+func (a *apiServer) feedAuthed(handler apiHandlerFunc) http.HandlerFunc {
+	authed := a.authed(handler)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("key"); key != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		authed(w, r)
+	}
+}
+
+// authenticate accepts either a per-user API key, which acts as its owner and
+// is limited to GET requests when read-only, or the server key from the
+// config. The server key acts as the user named in the X-Gator-User header,
+// or the CLI's current user when the header is absent.
+func (a *apiServer) authenticate(r *http.Request) (database.User, error) {
+	key, err := auth.GetAPIKey(r.Header)
+	if err != nil {
+		return database.User{}, unauthorized(err.Error())
+	}
+
+	if !auth.KeysEqual(key, a.serverKey) {
+		write := r.Method != http.MethodGet && r.Method != http.MethodHead
+		return a.userForKey(r.Context(), key, write)
+	}
+
+	ctx := r.Context()
+	if name := r.Header.Get("X-Gator-User"); name != "" {
+		user, err := a.state.DB.GetUser(ctx, name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.User{}, unauthorized(fmt.Sprintf("user '%s' not found", name))
+			}
+			return database.User{}, fmt.Errorf("failed to look up user: %w", err)
+		}
+		return user, nil
+	}
+
+	userID, err := uuid.Parse(a.state.Config.UserID)
+	if err != nil {
+		return database.User{}, unauthorized("server key requires an X-Gator-User header when no user is logged in")
+	}
+	user, err := a.state.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to fetch user from database: %w", err)
+	}
+	return user, nil
+}
+
+func unauthorized(msg string) error {
+	return &apiError{Status: http.StatusUnauthorized, Message: msg}
+}
+
+// userForKey resolves the owner of a per-user API key and records its use.
+// write reports whether the request changes data, which read-only keys may
+// not do.
+func (a *apiServer) userForKey(ctx context.Context, key string, write bool) (database.User, error) {
+	row, err := a.state.DB.GetUserByAPIKeyHash(ctx, auth.HashKey(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, unauthorized("invalid API key")
+		}
+		return database.User{}, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if write && row.Scope == auth.ScopeRead {
+		return database.User{}, &apiError{Status: http.StatusForbidden, Message: "API key is read-only"}
+	}
+
+	err = a.state.DB.TouchAPIKey(ctx, database.TouchAPIKeyParams{
+		LastUsedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:         row.ApiKeyID,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record API key use: %v", err)
+	}
+
+	return database.User{
+		ID:        row.ID,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+		Name:      row.Name,
+	}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// writeError reports err as a JSON error body.
+func writeError(w http.ResponseWriter, err error) {
+	status, msg := errorStatus(err)
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// errorStatus maps domain errors onto HTTP statuses. Unexpected errors are
+// logged and reported without detail.
+func errorStatus(err error) (int, string) {
+	status := http.StatusInternalServerError
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.Status
+	case errors.Is(err, ErrFeedNotFound), errors.Is(err, ErrPostNotFound), errors.Is(err, ErrNotFollowing):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrFeedExists), errors.Is(err, ErrAlreadyFollowing):
+		status = http.StatusConflict
+	case errors.Is(err, ErrInvalidPostRef), errors.Is(err, feed.ErrInvalidURL):
+		status = http.StatusBadRequest
+	}
+
+	if status == http.StatusInternalServerError {
+		log.Printf("Error handling API request: %v", err)
+		return status, "internal server error"
+	}
+	return status, err.Error()
+}
+
+// decodeJSON decodes the request body into v, rejecting unknown fields.
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+)
+
+// isFeedRef reports whether a tag target refers to a feed (by URL) rather
+// than to a post number, range or ID.
+func isFeedRef(target string) bool {
+	return strings.Contains(target, "://")
+}
+
+// normalizeTags lowercases and trims tag names and drops duplicates.
+func normalizeTags(args []string) ([]string, error) {
+	seen := make(map[string]bool, len(args))
+	tags := make([]string, 0, len(args))
+	for _, arg := range args {
+		tag := strings.ToLower(strings.TrimSpace(arg))
+		if tag == "" {
+			return nil, errors.New("tag names cannot be empty")
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// tagCount is the record tag and untag print for --output: how many tags
+// were added to or removed from a feed or posts.
+type tagCount struct {
+	Tags int64 `json:"tags"`
+}
+
+// printTagCount prints n as a tagCount record, or as text built from format
+// and args when --output is text.
+func printTagCount(s *state, n int64, format string, args ...any) error {
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, tagCount{Tags: n})
+	}
+	fmt.Printf(format, append([]any{n}, args...)...)
+	return nil
+}
+
+func handlerTag(s *state, cmd command, user database.User) error {
+	target := cmd.Args[0]
+	tags, err := normalizeTags(cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	if isFeedRef(target) {
+		feed, err := getFeedByURL(ctx, s.DB, s.URLs, target)
+		if err != nil {
+			return err
+		}
+		added, err := s.DB.TagFeed(ctx, database.TagFeedParams{
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			CreatedAt: now,
+			Tags:      tags,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag feed: %w", err)
+		}
+		return printTagCount(s, added, "Added %d tags to feed %s.\n", feed.Name)
+	}
+
+	added, err := forEachPostRange(ctx, s.DB, []string{target}, func(r postRange) (int64, error) {
+		n, err := s.DB.TagPosts(ctx, database.TagPostsParams{
+			UserID:    user.ID,
+			CreatedAt: now,
+			Tags:      tags,
+			FromSeq:   r.From,
+			ToSeq:     r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to tag posts: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTagCount(s, added, "Added %d post tags.\n")
+}
+
+func handlerUntag(s *state, cmd command, user database.User) error {
+	target := cmd.Args[0]
+	tags, err := normalizeTags(cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	if isFeedRef(target) {
+		feed, err := getFeedByURL(ctx, s.DB, s.URLs, target)
+		if err != nil {
+			return err
+		}
+		removed, err := s.DB.UntagFeed(ctx, database.UntagFeedParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Tags:   tags,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to untag feed: %w", err)
+		}
+		return printTagCount(s, removed, "Removed %d tags from feed %s.\n", feed.Name)
+	}
+
+	removed, err := forEachPostRange(ctx, s.DB, []string{target}, func(r postRange) (int64, error) {
+		n, err := s.DB.UntagPosts(ctx, database.UntagPostsParams{
+			UserID:  user.ID,
+			Tags:    tags,
+			FromSeq: r.From,
+			ToSeq:   r.To,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to untag posts: %w", err)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTagCount(s, removed, "Removed %d post tags.\n")
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/webhook"
+)
+
+// Webhook deliveries are sent in batches of webhookBatch per agg tick. A
+// claimed delivery is left alone by other aggregators for webhookLease, which
+// must outlast a batch of requests of up to webhookTimeout each.
+const (
+	webhookBatch   = 20
+	webhookLease   = 5 * time.Minute
+	webhookTimeout = 10 * time.Second
+)
+
+// defaultWebhookLogLimit is how many attempts webhook log shows by default.
+const defaultWebhookLogLimit = 20
+
+func handlerWebhookAdd(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	hookURL := cmd.Args[0]
+	if err := webhook.ValidURL(hookURL); err != nil {
+		return err
+	}
+
+	secret := cmd.String("secret")
+	generated := secret == ""
+	if generated {
+		var err error
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			return err
+		}
+	}
+
+	params := database.CreateWebhookParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Url:       hookURL,
+		Secret:    secret,
+	}
+	scope := "every new post in feeds you follow"
+	if feedURL := cmd.String("feed"); feedURL != "" {
+		f, err := getFeedByURL(ctx, s.DB, s.URLs, feedURL)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: f.ID, Valid: true}
+		scope = "new posts in " + f.Url
+	}
+	if name := cmd.String("rule"); name != "" {
+		rule, err := getRule(ctx, s.DB, user.ID, name)
+		if err != nil {
+			return err
+		}
+		params.RuleID = uuid.NullUUID{UUID: rule.Rule.ID, Valid: true}
+		if params.FeedID.Valid {
+			scope += fmt.Sprintf(" that rule '%s' matches", rule.Rule.Name)
+		} else {
+			scope = fmt.Sprintf("new posts that rule '%s' matches", rule.Rule.Name)
+		}
+	}
+
+	hook, err := s.DB.CreateWebhook(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, hook)
+	}
+	fmt.Printf("Added webhook %s for %s.\n", hook.ID, scope)
+	if generated {
+		fmt.Printf("Payloads are signed with HMAC-SHA256 in the %s header, using this secret:\n", webhook.SignatureHeader)
+		fmt.Printf("  %s\n", secret)
+	}
+	return nil
+}
+
+func handlerWebhookList(s *state, cmd command, user database.User) error {
+	hooks, err := s.DB.ListWebhooksForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, hooks)
+	}
+
+	if len(hooks) == 0 {
+		fmt.Println("You have no webhooks. Use 'gator webhook add <url>' to make one.")
+		return nil
+	}
+
+	fmt.Printf("You have %d webhooks:\n", len(hooks))
+	for _, hook := range hooks {
+		scope := "all new posts"
+		if hook.FeedUrl.Valid {
+			scope = "new posts in " + hook.FeedUrl.String
+		}
+		if hook.RuleName.Valid {
+			scope += fmt.Sprintf(" matching rule '%s'", hook.RuleName.String)
+		}
+		fmt.Printf("  - %s\n", hook.Url)
+		fmt.Printf("      ID: %s | %s\n", hook.ID, scope)
+		fmt.Printf("      %d delivered, %d pending, %d failed\n", hook.Delivered, hook.Pending, hook.Failed)
+	}
+	return nil
+}
+
+// webhooksRemoved is the record webhook remove prints for --output. A URL can
+// match more than one webhook.
+type webhooksRemoved struct {
+	Ref     string `json:"ref"`
+	Removed int64  `json:"removed"`
+}
+
+func handlerWebhookRemove(s *state, cmd command, user database.User) error {
+	ref := cmd.Args[0]
+	removed, err := s.DB.DeleteWebhook(context.Background(), database.DeleteWebhookParams{
+		UserID: user.ID,
+		Ref:    ref,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove webhook: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("no webhook with ID or URL '%s'", ref)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, webhooksRemoved{Ref: ref, Removed: removed})
+	}
+	fmt.Printf("Removed %d webhook(s) matching '%s'.\n", removed, ref)
+	return nil
+}
+
+// handlerWebhookLog shows the most recent delivery attempts, for all of the
+// user's webhooks or one of them.
+func handlerWebhookLog(s *state, cmd command, user database.User) error {
+	params := database.ListWebhookAttemptsParams{
+		UserID: user.ID,
+		Lim:    int32(cmd.Int("limit")),
+	}
+	if params.Lim <= 0 {
+		return fmt.Errorf("invalid limit %d: must be a positive integer", params.Lim)
+	}
+	if len(cmd.Args) == 1 {
+		id, err := uuid.Parse(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("invalid webhook ID '%s'", cmd.Args[0])
+		}
+		params.WebhookID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	attempts, err := s.DB.ListWebhookAttempts(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("failed to fetch delivery log: %w", err)
+	}
+
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, attempts)
+	}
+
+	if len(attempts) == 0 {
+		fmt.Println("No deliveries have been attempted yet.")
+		return nil
+	}
+
+	for _, a := range attempts {
+		status := "---"
+		if a.StatusCode.Valid {
+			status = fmt.Sprint(a.StatusCode.Int32)
+		}
+		fmt.Printf("  %s  %s  %s (attempt %d)\n", a.AttemptedAt.Local().Format("2006-01-02 15:04:05"), status, a.Url, a.Attempt)
+		fmt.Printf("      %d %s\n", a.PostSeq, a.PostTitle)
+		if a.Error.Valid {
+			fmt.Printf("      error: %s\n", a.Error.String)
+		}
+	}
+	return nil
+}
+
+// enqueueWebhooks adds a newly saved post to the outbox of each webhook it
+// should go to: those without a rule, and those whose rule matched it.
+func enqueueWebhooks(ctx context.Context, q *database.Queries, hooks []database.GetWebhooksForFeedRow, dbFeed database.Feed, post database.UpsertPostRow, matched map[uuid.UUID]bool, now time.Time) {
+	for _, hook := range hooks {
+		if hook.RuleID.Valid && !matched[hook.RuleID.UUID] {
+			continue
+		}
+		body, err := json.Marshal(webhook.Payload{
+			Event: webhook.EventNewPost,
+			User:  hook.UserName,
+			Rule:  hook.RuleName.String,
+			Post: webhook.Post{
+				ID:          post.ID,
+				Seq:         post.Seq,
+				Title:       post.Title,
+				URL:         post.Url,
+				Summary:     webhook.Summary(postBody(post.Description, post.Content)),
+				Author:      post.Author.String,
+				Language:    post.Language.String,
+				CommentsURL: post.CommentsUrl.String,
+				PublishedAt: post.PublishedAt,
+				Feed:        webhook.Feed{Name: dbFeed.Name, URL: dbFeed.Url},
+			},
+		})
+		if err != nil {
+			log.Printf("Error encoding webhook payload for %q: %v", post.Title, err)
+			continue
+		}
+		err = q.EnqueueWebhookDelivery(ctx, database.EnqueueWebhookDeliveryParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			WebhookID: hook.ID,
+			PostID:    post.ID,
+			Payload:   string(body),
+		})
+		if err != nil {
+			log.Printf("Error queueing webhook for %q: %v", post.Title, err)
+		}
+	}
+}
+
+// deliverWebhooks sends a batch of due deliveries from the outbox. Each
+// attempt is logged; failures are retried with exponential backoff until
+// webhook.MaxAttempts is reached.
+func deliverWebhooks(ctx context.Context, s *state, progress io.Writer) {
+	now := time.Now().UTC()
+	due, err := s.DB.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+		LeaseUntil: now.Add(webhookLease),
+		Now:        now,
+		Lim:        webhookBatch,
+	})
+	if err != nil {
+		log.Printf("Error fetching webhook deliveries: %v", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delivered, failed := 0, 0
+	for _, d := range due {
+		attempt := d.Attempts + 1
+		status, sendErr := webhook.Send(ctx, client, d.Url, d.Secret, d.ID, []byte(d.Payload))
+		at := time.Now().UTC()
+
+		record := database.RecordWebhookAttemptParams{
+			DeliveryID:  d.ID,
+			Attempt:     attempt,
+			AttemptedAt: at,
+			StatusCode:  sql.NullInt32{Int32: int32(status), Valid: status != 0},
+		}
+		update := database.UpdateWebhookDeliveryParams{Attempts: attempt, ID: d.ID}
+		if sendErr == nil {
+			update.DeliveredAt = sql.NullTime{Time: at, Valid: true}
+			delivered++
+		} else {
+			record.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+			if attempt < webhook.MaxAttempts {
+				update.NextAttemptAt = sql.NullTime{Time: at.Add(webhook.Backoff(int(attempt))), Valid: true}
+			} else {
+				log.Printf("Giving up on webhook delivery %s to %s after %d attempts: %v", d.ID, d.Url, attempt, sendErr)
+			}
+			failed++
+		}
+
+		err := s.withTx(ctx, func(q *database.Queries) error {
+			if err := q.RecordWebhookAttempt(ctx, record); err != nil {
+				return err
+			}
+			return q.UpdateWebhookDelivery(ctx, update)
+		})
+		if err != nil {
+			log.Printf("Error recording webhook delivery %s: %v", d.ID, err)
+		}
+	}
+	fmt.Fprintf(progress, "   Webhooks: %d delivered, %d failed.\n", delivered, failed)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// KeyPrefix marks gator API keys so they are easy to recognise in configs and logs.
+const KeyPrefix = "gator_"
+
+// Scopes a per-user API key can be granted.
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read-write"
+)
+
+var ErrNoAPIKey = errors.New("no API key provided")
+
+// ValidScope reports whether scope is one of the known key scopes.
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeReadWrite
+}
+
+// GenerateKey returns a new random API key.
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+// HashKey returns the hex SHA-256 digest stored in place of a key. Keys are
+// random and long, so a fast hash is sufficient.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeysEqual compares two keys in constant time.
+func KeysEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// GetAPIKey extracts the key from an "Authorization: Bearer <key>" header.
+func GetAPIKey(headers http.Header) (string, error) {
+	value := headers.Get("Authorization")
+	if value == "" {
+		return "", ErrNoAPIKey
+	}
+	scheme, key, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(key) == "" {
+		return "", errors.New("malformed authorization header: expected 'Bearer <key>'")
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// GetGoogleLoginToken extracts the token from an
+// "Authorization: GoogleLogin auth=<token>" header, as sent by Google Reader
+// API clients.
+func GetGoogleLoginToken(headers http.Header) (string, error) {
+	value := headers.Get("Authorization")
+	if value == "" {
+		return "", ErrNoAPIKey
+	}
+	scheme, param, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "GoogleLogin") {
+		return "", errors.New("malformed authorization header: expected 'GoogleLogin auth=<token>'")
+	}
+	name, token, ok := strings.Cut(strings.TrimSpace(param), "=")
+	if !ok || !strings.EqualFold(name, "auth") || strings.TrimSpace(token) == "" {
+		return "", errors.New("malformed authorization header: expected 'GoogleLogin auth=<token>'")
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -13,6 +13,42 @@ import (
 type Config struct {
 	UserID string `json:"user_id"`
 	APIKey string `json:"api_key"`
+	// IframeHosts lists the embed hosts whose iframes survive sanitizing.
+	// When unset, feed.DefaultIframeHosts applies; an empty list allows none.
+	IframeHosts []string `json:"iframe_hosts,omitzero"`
+	// TrackingParams lists the query parameters stripped from feed and post
+	// URLs; a trailing "*" matches a prefix. When unset,
+	// feed.DefaultTrackingParams applies.
+	TrackingParams []string `json:"tracking_params,omitzero"`
+	// MarkEditedUnread makes a post unread again for everyone when its feed
+	// publishes an edited version.
+	MarkEditedUnread bool `json:"mark_edited_unread,omitempty"`
+	// SMTP is the mail server digests are sent through.
+	SMTP *SMTP `json:"smtp,omitempty"`
+	// DigestTemplates is a directory of templates that replace the built-in
+	// digest templates; see gator digest --export-templates.
+	DigestTemplates string `json:"digest_templates,omitempty"`
+}
+
+// TLS modes for an SMTP server.
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+// SMTP holds the settings for sending mail.
+type SMTP struct {
+	Host string `json:"host"`
+	// Port defaults to 587 for starttls, 465 for tls and 25 for none.
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// From is the sender address, such as "Gator <gator@example.com>".
+	From string `json:"from"`
+	// TLS is starttls (the default), tls for a server that expects TLS from
+	// the start, or none for a local test server.
+	TLS string `json:"tls,omitempty"`
 }
 
 func Read() (Config, error) {
@@ -63,3 +99,8 @@ func (c *Config) SetUser(placeholderUserID string) error {
 	c.UserID = placeholderUserID
 	return c.Save()
 }
+
+func (c *Config) SetAPIKey(key string) error {
+	c.APIKey = key
+	return c.Save()
+}
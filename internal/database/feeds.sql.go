@@ -51,17 +51,18 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 }
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, user_id, feed_id
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, user_id, feed_id, category
 `
 
 type CreateFeedFollowParams struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	FeedID    uuid.UUID `json:"feed_id"`
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	FeedID    uuid.UUID      `json:"feed_id"`
+	Category  sql.NullString `json:"category"`
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (FeedFollow, error) {
@@ -71,6 +72,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Category,
 	)
 	var i FeedFollow
 	err := row.Scan(
@@ -79,11 +81,22 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Category,
 	)
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows
 WHERE feed_follows.user_id = $1
   AND feed_follows.feed_id = $2
@@ -94,9 +107,12 @@ type DeleteFeedFollowParams struct {
 	FeedID uuid.UUID `json:"feed_id"`
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
-	return err
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
@@ -121,7 +137,7 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 
 const getFeedFollowForUserAndFeed = `-- name: GetFeedFollowForUserAndFeed :one
 SELECT
-    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id,
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.category,
     users.name AS user_name,
     feeds.name AS feed_name
 FROM feed_follows
@@ -137,13 +153,14 @@ type GetFeedFollowForUserAndFeedParams struct {
 }
 
 type GetFeedFollowForUserAndFeedRow struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	FeedID    uuid.UUID `json:"feed_id"`
-	UserName  string    `json:"user_name"`
-	FeedName  string    `json:"feed_name"`
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	FeedID    uuid.UUID      `json:"feed_id"`
+	Category  sql.NullString `json:"category"`
+	UserName  string         `json:"user_name"`
+	FeedName  string         `json:"feed_name"`
 }
 
 func (q *Queries) GetFeedFollowForUserAndFeed(ctx context.Context, arg GetFeedFollowForUserAndFeedParams) (GetFeedFollowForUserAndFeedRow, error) {
@@ -155,6 +172,7 @@ func (q *Queries) GetFeedFollowForUserAndFeed(ctx context.Context, arg GetFeedFo
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Category,
 		&i.UserName,
 		&i.FeedName,
 	)
@@ -163,20 +181,33 @@ func (q *Queries) GetFeedFollowForUserAndFeed(ctx context.Context, arg GetFeedFo
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT
-    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id,
-    feeds.name AS feed_name
+    feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.category,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        LEFT JOIN post_reads ON post_reads.post_id = posts.id
+            AND post_reads.user_id = feed_follows.user_id
+        WHERE posts.feed_id = feed_follows.feed_id
+          AND post_reads.post_id IS NULL
+    ) AS unread_count
 FROM feed_follows
 JOIN feeds ON feed_follows.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.category ASC NULLS LAST, feeds.name ASC
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	FeedID    uuid.UUID `json:"feed_id"`
-	FeedName  string    `json:"feed_name"`
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	FeedID      uuid.UUID      `json:"feed_id"`
+	Category    sql.NullString `json:"category"`
+	FeedName    string         `json:"feed_name"`
+	FeedUrl     string         `json:"feed_url"`
+	UnreadCount int64          `json:"unread_count"`
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -194,7 +225,10 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Category,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.UnreadCount,
 		); err != nil {
 			return nil, err
 		}
@@ -283,6 +317,73 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 	return i, err
 }
 
+const listCategoriesForUser = `-- name: ListCategoriesForUser :many
+SELECT DISTINCT feed_follows.category::text AS category
+FROM feed_follows
+WHERE feed_follows.user_id = $1
+  AND feed_follows.category IS NOT NULL
+ORDER BY 1
+`
+
+func (q *Queries) ListCategoriesForUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoriesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, err
+		}
+		items = append(items, category)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeeds = `-- name: ListFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
 SET last_fetched_at = $1,
@@ -300,3 +401,75 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.UpdatedAt, arg.ID)
 	return err
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :execrows
+UPDATE feed_follows
+SET feed_id = $1, updated_at = $2
+WHERE feed_id = $3
+  AND NOT EXISTS (
+      SELECT 1 FROM feed_follows AS existing
+      WHERE existing.feed_id = $1
+        AND existing.user_id = feed_follows.user_id
+  )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID `json:"to_feed_id"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	FromFeedID uuid.UUID `json:"from_feed_id"`
+}
+
+// Moves follows to another feed; users already following it keep their own
+// follow and the moved one is left to be deleted with the old feed.
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.UpdatedAt, arg.FromFeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedFollowCategory = `-- name: SetFeedFollowCategory :execrows
+UPDATE feed_follows
+SET category = $1,
+    updated_at = $2
+WHERE feed_follows.user_id = $3
+  AND feed_follows.feed_id = $4
+`
+
+type SetFeedFollowCategoryParams struct {
+	Category  sql.NullString `json:"category"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	FeedID    uuid.UUID      `json:"feed_id"`
+}
+
+func (q *Queries) SetFeedFollowCategory(ctx context.Context, arg SetFeedFollowCategoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowCategory,
+		arg.Category,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedUrl = `-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedUrlParams struct {
+	Url       string    `json:"url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedUrl, arg.Url, arg.UpdatedAt, arg.ID)
+	return err
+}
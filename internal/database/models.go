@@ -11,6 +11,26 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID    `json:"id"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UserID     uuid.UUID    `json:"user_id"`
+	KeyHash    string       `json:"key_hash"`
+	Label      string       `json:"label"`
+	Scope      string       `json:"scope"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+}
+
+type DigestSchedule struct {
+	UserID     uuid.UUID    `json:"user_id"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Email      string       `json:"email"`
+	Schedule   string       `json:"schedule"`
+	Timezone   string       `json:"timezone"`
+	LastSentAt sql.NullTime `json:"last_sent_at"`
+}
+
 type Feed struct {
 	ID            uuid.UUID    `json:"id"`
 	CreatedAt     time.Time    `json:"created_at"`
@@ -22,22 +42,82 @@ type Feed struct {
 }
 
 type FeedFollow struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	FeedID    uuid.UUID      `json:"feed_id"`
+	Category  sql.NullString `json:"category"`
+}
+
+type FeedTag struct {
 	UserID    uuid.UUID `json:"user_id"`
 	FeedID    uuid.UUID `json:"feed_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Post struct {
-	ID          uuid.UUID      `json:"id"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	Title       string         `json:"title"`
-	Url         string         `json:"url"`
-	Description sql.NullString `json:"description"`
-	PublishedAt time.Time      `json:"published_at"`
-	FeedID      uuid.UUID      `json:"feed_id"`
+	ID           uuid.UUID      `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Title        string         `json:"title"`
+	Url          string         `json:"url"`
+	Description  sql.NullString `json:"description"`
+	PublishedAt  time.Time      `json:"published_at"`
+	FeedID       uuid.UUID      `json:"feed_id"`
+	Seq          int64          `json:"seq"`
+	Language     sql.NullString `json:"language"`
+	Content      sql.NullString `json:"content"`
+	Author       sql.NullString `json:"author"`
+	Guid         sql.NullString `json:"guid"`
+	CommentsUrl  sql.NullString `json:"comments_url"`
+	SearchVector interface{}    `json:"search_vector"`
+	ItemKey      string         `json:"item_key"`
+	Simhash      sql.NullInt64  `json:"simhash"`
+	StoryID      uuid.UUID      `json:"story_id"`
+}
+
+type PostHide struct {
+	UserID   uuid.UUID `json:"user_id"`
+	PostID   uuid.UUID `json:"post_id"`
+	HiddenAt time.Time `json:"hidden_at"`
+}
+
+type PostRead struct {
+	UserID uuid.UUID `json:"user_id"`
+	PostID uuid.UUID `json:"post_id"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+type PostStar struct {
+	UserID    uuid.UUID `json:"user_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	StarredAt time.Time `json:"starred_at"`
+}
+
+type PostTag struct {
+	UserID    uuid.UUID `json:"user_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Rule struct {
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Name      string         `json:"name"`
+	Scope     string         `json:"scope"`
+	Pattern   sql.NullString `json:"pattern"`
+	Keywords  []string       `json:"keywords"`
+	FeedID    uuid.NullUUID  `json:"feed_id"`
+	Author    sql.NullString `json:"author"`
+	Category  sql.NullString `json:"category"`
+	Language  sql.NullString `json:"language"`
+	Action    string         `json:"action"`
+	Tag       sql.NullString `json:"tag"`
 }
 
 type User struct {
@@ -46,3 +126,32 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Name      string    `json:"name"`
 }
+
+type Webhook struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Url       string        `json:"url"`
+	Secret    string        `json:"secret"`
+	FeedID    uuid.NullUUID `json:"feed_id"`
+	RuleID    uuid.NullUUID `json:"rule_id"`
+}
+
+type WebhookAttempt struct {
+	DeliveryID  uuid.UUID      `json:"delivery_id"`
+	Attempt     int32          `json:"attempt"`
+	AttemptedAt time.Time      `json:"attempted_at"`
+	StatusCode  sql.NullInt32  `json:"status_code"`
+	Error       sql.NullString `json:"error"`
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID    `json:"id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	WebhookID     uuid.UUID    `json:"webhook_id"`
+	PostID        uuid.UUID    `json:"post_id"`
+	Payload       string       `json:"payload"`
+	Attempts      int32        `json:"attempts"`
+	NextAttemptAt sql.NullTime `json:"next_attempt_at"`
+	DeliveredAt   sql.NullTime `json:"delivered_at"`
+}
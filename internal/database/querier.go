@@ -11,20 +11,126 @@ import (
 )
 
 type Querier interface {
+	// Records that a digest is being sent, unless another aggregator has done
+	// so since last_sent_at was read.
+	ClaimDigest(ctx context.Context, arg ClaimDigestParams) (int64, error)
+	// Takes up to lim deliveries that are due and pushes their next attempt back
+	// to lease_until, so that another aggregator leaves them alone while they
+	// are being sent.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	// Marks a post unread again for every user.
+	ClearPostReads(ctx context.Context, postID uuid.UUID) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error)
 	CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (FeedFollow, error)
+	CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	DeleteAPIKey(ctx context.Context, arg DeleteAPIKeyParams) (int64, error)
 	DeleteAllUsers(ctx context.Context) error
-	DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error
+	DeleteDigestSchedule(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteFeed(ctx context.Context, id uuid.UUID) error
+	DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error)
+	// Its reads, stars, hides and tags go with it; move them first to keep them.
+	DeletePost(ctx context.Context, id uuid.UUID) error
+	DeleteRule(ctx context.Context, arg DeleteRuleParams) (int64, error)
+	DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error)
+	EnqueueWebhookDelivery(ctx context.Context, arg EnqueueWebhookDeliveryParams) error
+	GetDigestSchedule(ctx context.Context, userID uuid.UUID) (DigestSchedule, error)
 	GetFeedByUrl(ctx context.Context, url string) (Feed, error)
 	GetFeedFollowForUserAndFeed(ctx context.Context, arg GetFeedFollowForUserAndFeedParams) (GetFeedFollowForUserAndFeedRow, error)
 	GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error)
 	GetFeedsWithUserName(ctx context.Context) ([]GetFeedsWithUserNameRow, error)
+	// Every post from a user's followed feeds published since a time, read,
+	// hidden or not, newest first. Rules are checked against these.
+	GetFollowedPostsSince(ctx context.Context, arg GetFollowedPostsSinceParams) ([]GetFollowedPostsSinceRow, error)
 	GetNextFeedToFetch(ctx context.Context) (Feed, error)
+	GetPostByID(ctx context.Context, id uuid.UUID) (GetPostByIDRow, error)
+	GetPostBySeq(ctx context.Context, seq int64) (GetPostBySeqRow, error)
+	// With collapse_stories, a story carried by several followed feeds is listed
+	// once, as its earliest copy among the posts that pass the other filters.
+	GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error)
+	GetRuleForUser(ctx context.Context, arg GetRuleForUserParams) (GetRuleForUserRow, error)
+	// The rules of every user who follows a feed, oldest first, with the
+	// category each follows it in.
+	GetRulesForFeed(ctx context.Context, feedID uuid.UUID) ([]GetRulesForFeedRow, error)
+	// Starred posts are listed regardless of whether the user still follows the feed.
+	GetStarredPostsForUser(ctx context.Context, userID uuid.UUID) ([]GetStarredPostsForUserRow, error)
+	// Posts from other feeds published around the same time, to compare a new
+	// post's simhash against.
+	GetStoryCandidates(ctx context.Context, arg GetStoryCandidatesParams) ([]GetStoryCandidatesRow, error)
+	// Backs the Google Reader stream endpoints. A label matches either the
+	// follow's category or a tag on the post or its feed. Starred posts stay in
+	// the starred stream, and can be fetched by id, after their feed is unfollowed.
+	GetStreamPosts(ctx context.Context, arg GetStreamPostsParams) ([]GetStreamPostsRow, error)
 	GetUser(ctx context.Context, name string) (User, error)
+	GetUserByAPIKeyHash(ctx context.Context, keyHash string) (GetUserByAPIKeyHashRow, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUsers(ctx context.Context) ([]User, error)
+	// The webhooks of every user who follows a feed that its new posts may go to.
+	GetWebhooksForFeed(ctx context.Context, feedID uuid.UUID) ([]GetWebhooksForFeedRow, error)
+	HidePosts(ctx context.Context, arg HidePostsParams) (int64, error)
+	ListAPIKeysForUser(ctx context.Context, userID uuid.UUID) ([]ListAPIKeysForUserRow, error)
+	ListCategoriesForUser(ctx context.Context, userID uuid.UUID) ([]string, error)
+	ListDigestSchedules(ctx context.Context) ([]ListDigestSchedulesRow, error)
+	ListFeeds(ctx context.Context) ([]Feed, error)
+	ListPostIdentities(ctx context.Context) ([]ListPostIdentitiesRow, error)
+	ListRulesForUser(ctx context.Context, userID uuid.UUID) ([]ListRulesForUserRow, error)
+	ListTagsForUser(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// The delivery log of a user's webhooks, or of one of them, newest first.
+	ListWebhookAttempts(ctx context.Context, arg ListWebhookAttemptsParams) ([]ListWebhookAttemptsRow, error)
+	ListWebhooksForUser(ctx context.Context, userID uuid.UUID) ([]ListWebhooksForUserRow, error)
+	MarkAllPostsRead(ctx context.Context, arg MarkAllPostsReadParams) (int64, error)
 	MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error
+	// Marks one post read without the rest of its story, for rules that
+	// matched that copy alone.
+	MarkPostRead(ctx context.Context, arg MarkPostReadParams) (int64, error)
+	// Reading a post reads its whole story.
+	MarkPostsRead(ctx context.Context, arg MarkPostsReadParams) (int64, error)
+	// Marking a post unread does so for its whole story.
+	MarkPostsUnread(ctx context.Context, arg MarkPostsUnreadParams) (int64, error)
+	// Moves follows to another feed; users already following it keep their own
+	// follow and the moved one is left to be deleted with the old feed.
+	MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) (int64, error)
+	MoveFeedRules(ctx context.Context, arg MoveFeedRulesParams) (int64, error)
+	MoveFeedTags(ctx context.Context, arg MoveFeedTagsParams) (int64, error)
+	MoveFeedWebhooks(ctx context.Context, arg MoveFeedWebhooksParams) (int64, error)
+	MovePostHides(ctx context.Context, arg MovePostHidesParams) (int64, error)
+	MovePostReads(ctx context.Context, arg MovePostReadsParams) (int64, error)
+	MovePostStars(ctx context.Context, arg MovePostStarsParams) (int64, error)
+	MovePostTags(ctx context.Context, arg MovePostTagsParams) (int64, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	// Posts saved before GUIDs were stored are keyed by the link they were saved
+	// under, which is the raw link unless dedupe has since canonicalized it. This
+	// gives the oldest such post the item's current key and canonical link, so
+	// that the item is recognised instead of being saved a second time. Items
+	// without a GUID are re-keyed from their raw link to their canonical one.
+	RekeyLegacyPost(ctx context.Context, arg RekeyLegacyPostParams) (int64, error)
+	ResetDigestSent(ctx context.Context, arg ResetDigestSentParams) error
+	// The query is parsed once for each text search configuration in use, and
+	// each post is matched against the parse for its own language. Stemming and
+	// exclusions ("-word") follow the post's language, and the match can still
+	// use the search vector index.
+	SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error)
+	SetDigestSchedule(ctx context.Context, arg SetDigestScheduleParams) (DigestSchedule, error)
+	SetFeedFollowCategory(ctx context.Context, arg SetFeedFollowCategoryParams) (int64, error)
+	SetFeedUrl(ctx context.Context, arg SetFeedUrlParams) error
+	SetPostIdentity(ctx context.Context, arg SetPostIdentityParams) error
+	SetPostStory(ctx context.Context, arg SetPostStoryParams) error
+	// Starring does not depend on still following the post's feed, like the
+	// starred list, so a post stays starrable after its feed is unfollowed.
+	StarPosts(ctx context.Context, arg StarPostsParams) (int64, error)
+	TagFeed(ctx context.Context, arg TagFeedParams) (int64, error)
+	TagPosts(ctx context.Context, arg TagPostsParams) (int64, error)
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
+	UnstarPosts(ctx context.Context, arg UnstarPostsParams) (int64, error)
+	UntagFeed(ctx context.Context, arg UntagFeedParams) (int64, error)
+	UntagPosts(ctx context.Context, arg UntagPostsParams) (int64, error)
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error
+	// Items are identified within their feed by item_key. A re-fetched item only
+	// touches the stored post when its text has changed, keeping the post's ID,
+	// number and publication date; unchanged items return no row.
+	UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,116 @@
+// Package digest groups unread posts into a digest and renders it as text and
+// HTML for mailing.
+package digest
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Uncategorized names the group of posts from feeds followed without a
+// category.
+const Uncategorized = "Uncategorized"
+
+// Digest is what the templates render.
+type Digest struct {
+	User       string
+	Since      time.Time
+	Until      time.Time
+	Count      int
+	Categories []Category
+}
+
+// Category is the posts from the feeds a user follows in one category.
+type Category struct {
+	Name  string
+	Count int
+	Feeds []Feed
+}
+
+// Feed is the posts from one feed.
+type Feed struct {
+	Name  string
+	URL   string
+	Posts []Post
+}
+
+// Post is a single post in a digest. Summary is plain text.
+type Post struct {
+	Seq         int64
+	Title       string
+	URL         string
+	Author      string
+	Summary     string
+	PublishedAt time.Time
+	// AlsoIn names the other feeds that carried the same story.
+	AlsoIn []string
+}
+
+// Entry is a post along with where it belongs in the digest.
+type Entry struct {
+	Category string
+	FeedName string
+	FeedURL  string
+	Post     Post
+}
+
+// Build groups entries by category, then feed. Categories and feeds are
+// sorted by name, with uncategorized posts last; posts keep the order they
+// were given in. Times are shown in loc.
+func Build(user string, since, until time.Time, loc *time.Location, entries []Entry) Digest {
+	d := Digest{
+		User:  user,
+		Since: since.In(loc),
+		Until: until.In(loc),
+		Count: len(entries),
+	}
+
+	categories := map[string]*Category{}
+	feeds := map[string]map[string]*Feed{}
+	var order []string
+	for _, e := range entries {
+		name := e.Category
+		if name == "" {
+			name = Uncategorized
+		}
+		c, ok := categories[name]
+		if !ok {
+			c = &Category{Name: name}
+			categories[name] = c
+			feeds[name] = map[string]*Feed{}
+			order = append(order, name)
+		}
+		c.Count++
+
+		f, ok := feeds[name][e.FeedURL]
+		if !ok {
+			f = &Feed{Name: e.FeedName, URL: e.FeedURL}
+			feeds[name][e.FeedURL] = f
+		}
+		post := e.Post
+		post.PublishedAt = post.PublishedAt.In(loc)
+		f.Posts = append(f.Posts, post)
+	}
+
+	slices.SortFunc(order, func(a, b string) int {
+		if (a == Uncategorized) != (b == Uncategorized) {
+			if a == Uncategorized {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a, b)
+	})
+	for _, name := range order {
+		c := categories[name]
+		for _, f := range feeds[name] {
+			c.Feeds = append(c.Feeds, *f)
+		}
+		slices.SortFunc(c.Feeds, func(a, b Feed) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.URL, b.URL))
+		})
+		d.Categories = append(d.Categories, *c)
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is when a digest goes out: every day, or one day a week, at a
+// time of day in the user's timezone.
+type Schedule struct {
+	Weekly  bool
+	Weekday time.Weekday
+	Hour    int
+	Minute  int
+}
+
+// defaultSchedule is what "daily" and "weekly" mean without a day or time.
+var defaultSchedule = Schedule{Weekday: time.Monday, Hour: 7}
+
+// ParseSchedule reads a schedule such as "daily", "daily at 07:00",
+// "weekly on friday" or "weekly on monday at 18:30".
+func ParseSchedule(spec string) (Schedule, error) {
+	invalid := fmt.Errorf("invalid schedule '%s': expected something like \"daily at 07:00\" or \"weekly on monday at 07:00\"", spec)
+
+	words := strings.Fields(strings.ToLower(spec))
+	if len(words) == 0 {
+		return Schedule{}, invalid
+	}
+	s := defaultSchedule
+	switch words[0] {
+	case "daily":
+	case "weekly":
+		s.Weekly = true
+	default:
+		return Schedule{}, invalid
+	}
+	words = words[1:]
+
+	if s.Weekly && len(words) >= 2 && words[0] == "on" {
+		day, ok := parseWeekday(words[1])
+		if !ok {
+			return Schedule{}, fmt.Errorf("invalid schedule '%s': unknown day '%s'", spec, words[1])
+		}
+		s.Weekday = day
+		words = words[2:]
+	}
+	if len(words) >= 2 && words[0] == "at" {
+		t, err := time.Parse("15:04", words[1])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid schedule '%s': expected a 24-hour time such as 07:00", spec)
+		}
+		s.Hour, s.Minute = t.Hour(), t.Minute()
+		words = words[2:]
+	}
+	if len(words) > 0 {
+		return Schedule{}, invalid
+	}
+	return s, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.TrimSuffix(name, "s")
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the schedule in the form ParseSchedule reads.
+func (s Schedule) String() string {
+	if s.Weekly {
+		return fmt.Sprintf("weekly on %s at %02d:%02d", strings.ToLower(s.Weekday.String()), s.Hour, s.Minute)
+	}
+	return fmt.Sprintf("daily at %02d:%02d", s.Hour, s.Minute)
+}
+
+// Period is the time between two digests.
+func (s Schedule) Period() time.Duration {
+	if s.Weekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Next returns the first time after after that the schedule falls on, in loc.
+// On the day a daylight saving change skips the schedule's time of day, it
+// falls an hour off.
+func (s Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc)
+	next := time.Date(t.Year(), t.Month(), t.Day(), s.Hour, s.Minute, 0, 0, loc)
+	for !next.After(after) || (s.Weekly && next.Weekday() != s.Weekday) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, s.Hour, s.Minute, 0, 0, loc)
+	}
+	return next
+}
@@ -0,0 +1,125 @@
+package digest
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Schedule
+	}{
+		{"daily", Schedule{Weekday: time.Monday, Hour: 7}},
+		{"daily at 18:30", Schedule{Weekday: time.Monday, Hour: 18, Minute: 30}},
+		{"  Daily  AT 00:05 ", Schedule{Weekday: time.Monday, Minute: 5}},
+		{"weekly", Schedule{Weekly: true, Weekday: time.Monday, Hour: 7}},
+		{"weekly on friday", Schedule{Weekly: true, Weekday: time.Friday, Hour: 7}},
+		{"weekly on sundays at 23:59", Schedule{Weekly: true, Weekday: time.Sunday, Hour: 23, Minute: 59}},
+		{"weekly on Wed at 9:00", Schedule{Weekly: true, Weekday: time.Wednesday, Hour: 9}},
+		{"weekly at 06:00", Schedule{Weekly: true, Weekday: time.Monday, Hour: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			again, err := ParseSchedule(got.String())
+			if err != nil || again != got {
+				t.Errorf("ParseSchedule(%q) = %+v, %v; want it to read back as %+v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"hourly",
+		"daily on monday",
+		"daily at",
+		"daily at 25:00",
+		"daily at 7pm",
+		"weekly on someday",
+		"weekly on",
+		"daily at 07:00 please",
+	} {
+		if got, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = %+v, want an error", spec, got)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	daily7 := Schedule{Hour: 7}
+	monday7 := Schedule{Weekly: true, Weekday: time.Monday, Hour: 7}
+	const layout = "2006-01-02 15:04 MST Mon"
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		loc      *time.Location
+		want     string
+	}{
+		{"daily later today", daily7, time.Date(2025, 6, 10, 6, 59, 0, 0, berlin), berlin, "2025-06-10 07:00 CEST Tue"},
+		{"daily at the time", daily7, time.Date(2025, 6, 10, 7, 0, 0, 0, berlin), berlin, "2025-06-11 07:00 CEST Wed"},
+		{"daily month rollover", daily7, time.Date(2025, 6, 30, 8, 0, 0, 0, berlin), berlin, "2025-07-01 07:00 CEST Tue"},
+		{"daily year rollover", daily7, time.Date(2025, 12, 31, 23, 0, 0, 0, berlin), berlin, "2026-01-01 07:00 CET Thu"},
+		{"weekly same day before", monday7, time.Date(2025, 6, 9, 6, 0, 0, 0, berlin), berlin, "2025-06-09 07:00 CEST Mon"},
+		{"weekly same day after", monday7, time.Date(2025, 6, 9, 7, 0, 0, 0, berlin), berlin, "2025-06-16 07:00 CEST Mon"},
+		{"weekly from sunday night", monday7, time.Date(2025, 6, 15, 23, 30, 0, 0, berlin), berlin, "2025-06-16 07:00 CEST Mon"},
+		{"weekly year rollover", monday7, time.Date(2025, 12, 31, 12, 0, 0, 0, berlin), berlin, "2026-01-05 07:00 CET Mon"},
+		{"weekly saturday", Schedule{Weekly: true, Weekday: time.Saturday, Hour: 18, Minute: 30},
+			time.Date(2025, 6, 10, 12, 0, 0, 0, berlin), berlin, "2025-06-14 18:30 CEST Sat"},
+		{"spring forward", daily7, time.Date(2025, 3, 29, 8, 0, 0, 0, berlin), berlin, "2025-03-30 07:00 CEST Sun"},
+		{"fall back", daily7, time.Date(2025, 10, 25, 8, 0, 0, 0, berlin), berlin, "2025-10-26 07:00 CET Sun"},
+		{"weekly across spring forward", monday7, time.Date(2025, 3, 24, 7, 0, 0, 0, berlin), berlin, "2025-03-31 07:00 CEST Mon"},
+		{"skipped time of day", Schedule{Hour: 2, Minute: 30}, time.Date(2025, 3, 29, 3, 0, 0, 0, berlin), berlin, "2025-03-30 03:30 CEST Sun"},
+		{"other zones", daily7, time.Date(2025, 11, 2, 12, 0, 0, 0, time.UTC), newYork, "2025-11-03 07:00 EST Mon"},
+		{"after in utc, next day in loc", daily7, time.Date(2025, 6, 10, 22, 30, 0, 0, time.UTC), tokyo, "2025-06-12 07:00 JST Thu"},
+		{"after in utc, same day in loc", daily7, time.Date(2025, 6, 10, 21, 30, 0, 0, time.UTC), tokyo, "2025-06-11 07:00 JST Wed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.Next(tt.after, tt.loc)
+			if s := got.Format(layout); s != tt.want {
+				t.Errorf("Next(%s) = %s, want %s", tt.after.Format(layout), s, tt.want)
+			}
+			if !got.After(tt.after) {
+				t.Errorf("Next(%s) = %s, not after it", tt.after.Format(layout), got.Format(layout))
+			}
+		})
+	}
+}
+
+func TestScheduleNextAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily7 := Schedule{Hour: 7}
+	before := daily7.Next(time.Date(2025, 3, 29, 8, 0, 0, 0, berlin), berlin)
+	if got := before.Sub(time.Date(2025, 3, 29, 7, 0, 0, 0, berlin)); got != 23*time.Hour {
+		t.Errorf("daily digest across spring forward %v apart, want 23h", got)
+	}
+	after := daily7.Next(time.Date(2025, 10, 25, 8, 0, 0, 0, berlin), berlin)
+	if got := after.Sub(time.Date(2025, 10, 25, 7, 0, 0, 0, berlin)); got != 25*time.Hour {
+		t.Errorf("daily digest across fall back %v apart, want 25h", got)
+	}
+}
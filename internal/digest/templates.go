@@ -0,0 +1,136 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Numpkens/gatorcli/internal/term"
+)
+
+// Template file names. A template directory may hold either or both; missing
+// ones fall back to the built-in templates.
+const (
+	TextTemplate = "digest.txt.tmpl"
+	HTMLTemplate = "digest.html.tmpl"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+// funcs are available to both templates.
+var funcs = map[string]any{
+	"join": strings.Join,
+	"wrap": wrap,
+}
+
+// Templates renders digests.
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplates parses the digest templates in dir, using the built-in ones
+// for any that are missing. An empty dir uses only the built-in templates.
+func LoadTemplates(dir string) (*Templates, error) {
+	textSrc, err := templateSource(dir, TextTemplate)
+	if err != nil {
+		return nil, err
+	}
+	htmlSrc, err := templateSource(dir, HTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Templates
+	if t.text, err = texttemplate.New(TextTemplate).Funcs(funcs).Parse(textSrc); err != nil {
+		return nil, fmt.Errorf("failed to parse digest template: %w", err)
+	}
+	if t.text.Lookup("subject") == nil {
+		return nil, fmt.Errorf("%s must define a \"subject\" template", TextTemplate)
+	}
+	if t.html, err = htmltemplate.New(HTMLTemplate).Funcs(funcs).Parse(htmlSrc); err != nil {
+		return nil, fmt.Errorf("failed to parse digest template: %w", err)
+	}
+	return &t, nil
+}
+
+func templateSource(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read digest template: %w", err)
+		}
+	}
+	data, err := builtin.ReadFile("templates/" + name)
+	return string(data), err
+}
+
+// Render returns a digest's subject line, plain-text body and HTML body.
+func (t *Templates) Render(d Digest) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err := t.text.ExecuteTemplate(&buf, "subject", d); err != nil {
+		return "", "", "", fmt.Errorf("failed to render digest subject: %w", err)
+	}
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.text.Execute(&buf, d); err != nil {
+		return "", "", "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err := t.html.Execute(&buf, d); err != nil {
+		return "", "", "", fmt.Errorf("failed to render HTML digest: %w", err)
+	}
+	return subject, text, buf.String(), nil
+}
+
+// ExportTemplates writes the built-in templates to dir as a starting point
+// for editing. Existing files are left alone and reported as an error.
+func ExportTemplates(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create template directory: %w", err)
+	}
+	var written []string
+	for _, name := range []string{TextTemplate, HTMLTemplate} {
+		data, err := builtin.ReadFile("templates/" + name)
+		if err != nil {
+			return written, err
+		}
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return written, fmt.Errorf("%s already exists; remove it to export a fresh copy", path)
+			}
+			return written, fmt.Errorf("failed to write template: %w", err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to write template: %w", err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// wrap breaks text into lines of at most width cells, joined by a newline
+// and indent.
+func wrap(text string, width int, indent string) string {
+	return strings.Join(term.Wrap(text, width), "\n"+indent)
+}
@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"html"
+	"strings"
+)
+
+type atomFeed struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Lang     string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Base     string      `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Links    []atomLink  `xml:"link"`
+	Authors  []atomName  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Authors   []atomName `xml:"author"`
+	Lang      string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Base      string     `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomName struct {
+	Name string `xml:"name"`
+}
+
+// atomText is an Atom text construct: plain text, escaped HTML or inline
+// XHTML depending on its type attribute.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Base  string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// html returns the construct as HTML.
+func (t atomText) html() string {
+	switch t.Type {
+	case "html", "text/html":
+		return strings.TrimSpace(t.Text)
+	case "xhtml", "application/xhtml+xml":
+		return strings.TrimSpace(t.Inner)
+	default:
+		return html.EscapeString(strings.TrimSpace(t.Text))
+	}
+}
+
+// plain returns the construct as plain text, as RSS titles are.
+func (t atomText) plain() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// link returns the href of the first link with the given relation. An
+// absent rel attribute means "alternate".
+func link(links []atomLink, rel string) string {
+	for _, l := range links {
+		r := l.Rel
+		if r == "" {
+			r = "alternate"
+		}
+		if r == rel && l.Href != "" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func authorName(authors []atomName) string {
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// joinBase combines an entry's xml:base with a nested one, leaving the result
+// relative if both are; the feed's base is applied on top when sanitizing.
+func joinBase(outer, inner string) string {
+	if inner == "" {
+		return outer
+	}
+	if u := resolveBase(nil, outer, inner); u != nil {
+		return u.String()
+	}
+	return outer
+}
+
+// rss converts the feed to the RSS shape the rest of gator works with.
+func (f *atomFeed) rss() *RSSFeed {
+	out := &RSSFeed{}
+	out.Channel.Title = f.Title.plain()
+	out.Channel.Link = link(f.Links, "alternate")
+	out.Channel.Description = f.Subtitle.plain()
+	out.Channel.Language = f.Lang
+	out.Channel.Base = f.Base
+
+	feedAuthor := authorName(f.Authors)
+	for _, e := range f.Entries {
+		item := RSSItem{
+			Title:       e.Title.plain(),
+			Link:        link(e.Links, "alternate"),
+			Description: e.Summary.html(),
+			Content:     e.Content.html(),
+			Author:      authorName(e.Authors),
+			GUID:        strings.TrimSpace(e.ID),
+			Comments:    link(e.Links, "replies"),
+			PubDate:     e.Published,
+			Language:    e.Lang,
+			Base:        joinBase(e.Base, e.Content.Base),
+		}
+		if item.PubDate == "" {
+			item.PubDate = e.Updated
+		}
+		if item.Author == "" {
+			item.Author = feedAuthor
+		}
+		out.Channel.Item = append(out.Channel.Item, item)
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidURL is returned by Canonicalize for anything but an absolute
+// http or https URL.
+var ErrInvalidURL = errors.New("invalid URL")
+
+// DefaultTrackingParams are the query parameters stripped from URLs when no
+// list is configured. A trailing "*" matches any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"yclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"mkt_tok",
+}
+
+// Canonicalizer reduces URLs to one spelling so that feeds and posts reached
+// by different URLs are recognised as the same.
+type Canonicalizer struct {
+	// TrackingParams lists the query parameters to strip.
+	TrackingParams []string
+}
+
+// NewCanonicalizer returns a canonicalizer stripping trackingParams, or
+// DefaultTrackingParams when trackingParams is nil.
+func NewCanonicalizer(trackingParams []string) *Canonicalizer {
+	if trackingParams == nil {
+		trackingParams = DefaultTrackingParams
+	}
+	return &Canonicalizer{TrackingParams: trackingParams}
+}
+
+// Canonicalize returns the canonical form of an absolute http or https URL:
+// lowercase scheme and host, the host in punycode, no default port, no
+// trailing slash on the path, and the query sorted with tracking parameters
+// removed. The fragment is kept: feeds that publish several entries on one
+// page tell them apart by it.
+func (c *Canonicalizer) Canonicalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("%w '%s': %v", ErrInvalidURL, raw, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w '%s': expected an http or https URL", ErrInvalidURL, raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%w '%s': missing host", ErrInvalidURL, raw)
+	}
+
+	host, port := strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), u.Port()
+	if net.ParseIP(host) == nil {
+		host, err = idna.Lookup.ToASCII(host)
+		if err != nil {
+			return "", fmt.Errorf("%w '%s': bad host: %v", ErrInvalidURL, raw, err)
+		}
+	}
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	// Trim on the escaped path so an encoded "/" keeps its meaning.
+	path := u.EscapedPath()
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	if path == "" {
+		path = "/"
+	}
+	if u.Path, err = url.PathUnescape(path); err != nil {
+		return "", fmt.Errorf("%w '%s': bad path: %v", ErrInvalidURL, raw, err)
+	}
+	u.RawPath = path
+
+	query := u.Query()
+	for key := range query {
+		if c.isTracking(key) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+func (c *Canonicalizer) isTracking(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range c.TrackingParams {
+		p = strings.ToLower(p)
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemeVariants returns a canonical URL followed by its spelling with the
+// other of http and https, so lookups can find a feed stored under either.
+func SchemeVariants(canonical string) []string {
+	if rest, ok := strings.CutPrefix(canonical, "https://"); ok {
+		return []string{canonical, "http://" + rest}
+	}
+	if rest, ok := strings.CutPrefix(canonical, "http://"); ok {
+		return []string{canonical, "https://" + rest}
+	}
+	return []string{canonical}
+}
+
+// Canonicalize rewrites the channel and item links to their canonical form.
+// Links that cannot be parsed are left alone.
+func (f *RSSFeed) Canonicalize(c *Canonicalizer) {
+	if link, err := c.Canonicalize(f.Channel.Link); err == nil {
+		f.Channel.Link = link
+	}
+	for i := range f.Channel.Item {
+		item := &f.Channel.Item[i]
+		if link, err := c.Canonicalize(item.Link); err == nil {
+			item.Link = link
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"http", "http://x.com/feed", "http://x.com/feed"},
+		{"trailing slash", "https://x.com/feed/", "https://x.com/feed"},
+		{"host case and utm", "https://X.com/feed?utm_source=y", "https://x.com/feed"},
+		{"root path", "https://x.com", "https://x.com/"},
+		{"root slash kept", "https://x.com/", "https://x.com/"},
+		{"default http port", "http://x.com:80/feed", "http://x.com/feed"},
+		{"default https port", "https://x.com:443/feed", "https://x.com/feed"},
+		{"other port", "https://x.com:8443/feed", "https://x.com:8443/feed"},
+		{"https port on http", "http://x.com:443/feed", "http://x.com:443/feed"},
+		{"scheme case", "HTTPS://x.com/feed", "https://x.com/feed"},
+		{"trailing dot", "https://x.com./feed", "https://x.com/feed"},
+		{"idna", "https://Bücher.example/feed", "https://xn--bcher-kva.example/feed"},
+		{"punycode kept", "https://xn--bcher-kva.example/feed", "https://xn--bcher-kva.example/feed"},
+		{"ipv6", "http://[2001:DB8::1]/feed", "http://[2001:db8::1]/feed"},
+		{"ipv6 default port", "https://[2001:db8::1]:443/feed", "https://[2001:db8::1]/feed"},
+		{"ipv6 port", "https://[2001:db8::1]:8080/feed", "https://[2001:db8::1]:8080/feed"},
+		{"escaped slash", "https://x.com/a%2Fb/", "https://x.com/a%2Fb"},
+		{"escaped trailing slash", "https://x.com/a%2F", "https://x.com/a%2F"},
+		{"utm prefix", "https://x.com/p?utm_medium=rss&utm_campaign=c&UTM_Content=d", "https://x.com/p"},
+		{"utm without underscore", "https://x.com/p?utmost=1", "https://x.com/p?utmost=1"},
+		{"exact tracking param", "https://x.com/p?fbclid=abc&id=7", "https://x.com/p?id=7"},
+		{"query sorted", "https://x.com/p?b=2&a=1&a=0", "https://x.com/p?a=1&a=0&b=2"},
+		{"query re-encoded", "https://x.com/p?q=a b&r=%7e", "https://x.com/p?q=a+b&r=~"},
+		{"empty query", "https://x.com/p?", "https://x.com/p"},
+		{"only tracking query", "https://x.com/p?utm_source=y", "https://x.com/p"},
+		{"fragment kept", "https://x.com/page#entry-2", "https://x.com/page#entry-2"},
+		{"surrounding space", "  https://x.com/feed  ", "https://x.com/feed"},
+	}
+
+	c := NewCanonicalizer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Canonicalize(tt.in)
+			if err != nil {
+				t.Fatalf("Canonicalize(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Canonicalize(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeInvalid(t *testing.T) {
+	c := NewCanonicalizer(nil)
+	for _, in := range []string{
+		"",
+		"/relative/feed",
+		"ftp://x.com/feed",
+		"javascript:alert(1)",
+		"https:///feed",
+		"https://x.com:bad/feed",
+	} {
+		if got, err := c.Canonicalize(in); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Canonicalize(%q) = %q, %v; want ErrInvalidURL", in, got, err)
+		}
+	}
+}
+
+func TestCanonicalizeTrackingParams(t *testing.T) {
+	c := NewCanonicalizer([]string{"ref", "pk_*"})
+	got, err := c.Canonicalize("https://x.com/p?ref=home&pk_campaign=c&utm_source=y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://x.com/p?utm_source=y"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
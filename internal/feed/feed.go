@@ -1,12 +1,16 @@
 package feed
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html/charset"
 	"html"
@@ -14,19 +18,53 @@ import (
 
 type RSSFeed struct {
 	XMLName xml.Name `xml:"rss"` // Required to match the root element 'rss'
+	Base    string   `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Channel struct {
+		Base        string    `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 		Title       string    `xml:"title"`
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
+		Language    string    `xml:"language"`
 		Item        []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
+// RSSItem is one feed entry. Description holds the summary and Content the
+// full article body, from content:encoded in RSS or <content> in Atom.
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	GUID        string `xml:"guid"`
+	Comments    string `xml:"comments"`
 	PubDate     string `xml:"pubDate"`
+	Language    string `xml:"http://purl.org/dc/elements/1.1/ language"`
+	Base        string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
+// Body returns the richer of the item's content and summary.
+func (item RSSItem) Body() string {
+	if len(item.Content) > len(item.Description) {
+		return item.Content
+	}
+	return item.Description
+}
+
+// Key identifies the item within its feed: its GUID or Atom id when it has
+// one, otherwise its link, otherwise a hash of its title and body. Links are
+// expected to have been normalized by Sanitize.
+func (item RSSItem) Key() string {
+	if item.GUID != "" {
+		return "guid:" + item.GUID
+	}
+	if item.Link != "" {
+		return "link:" + item.Link
+	}
+	sum := sha256.Sum256([]byte(item.Title + "\n" + item.Body()))
+	return "hash:" + hex.EncodeToString(sum[:16])
 }
 
 func unescapeHTMLFields(feed *RSSFeed) {
@@ -37,6 +75,14 @@ func unescapeHTMLFields(feed *RSSFeed) {
 		item := &feed.Channel.Item[i]
 		item.Title = html.UnescapeString(item.Title)
 		item.Description = html.UnescapeString(item.Description)
+		// RSS author is an email address, optionally followed by the name;
+		// dc:creator, where present, is just the name.
+		if item.Creator != "" {
+			item.Author = item.Creator
+		}
+		item.Author = html.UnescapeString(strings.TrimSpace(item.Author))
+		item.GUID = strings.TrimSpace(item.GUID)
+		item.Comments = strings.TrimSpace(item.Comments)
 	}
 }
 
@@ -64,16 +110,67 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var rssFeed RSSFeed
+	return ParseFeed(data)
+}
 
-	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+// ParseFeed decodes an RSS 2.0 or Atom document. Atom feeds are returned in
+// the RSS shape.
+func ParseFeed(data []byte) (*RSSFeed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
 	decoder.CharsetReader = charset.NewReaderLabel
 
-	if err := decoder.Decode(&rssFeed); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+	var root xml.StartElement
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	switch root.Name.Local {
+	case "rss":
+		var rssFeed RSSFeed
+		if err := decoder.DecodeElement(&rssFeed, &root); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+		}
+		unescapeHTMLFields(&rssFeed)
+		return &rssFeed, nil
+	case "feed":
+		var atom atomFeed
+		if err := decoder.DecodeElement(&atom, &root); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+		}
+		return atom.rss(), nil
+	default:
+		return nil, fmt.Errorf("unsupported feed format: root element <%s>", root.Name.Local)
 	}
+}
 
-	unescapeHTMLFields(&rssFeed)
+// pubDateLayouts lists the date formats seen in the wild for RSS pubDate and
+// Atom updated/published elements, most common first.
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
 
-	return &rssFeed, nil
+// ParseDate parses a feed timestamp in any of the common RSS/Atom layouts.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %q", value)
 }
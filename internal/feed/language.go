@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeLanguage reduces a BCP 47 tag such as "en-US" or "de_DE" to its
+// base ISO 639-1 code ("en", "de"). It returns "" for empty or unparseable tags.
+func NormalizeLanguage(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" {
+		return ""
+	}
+	t, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	base, confidence := t.Base()
+	if confidence == language.No {
+		return ""
+	}
+	return base.String()
+}
+
+// stopwords holds very common function words for the Latin-script languages
+// we can tell apart cheaply. They are only used when a feed does not declare
+// its language.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this", "you"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "den", "auf", "für", "sich", "auch", "dem"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "pour", "dans", "que", "pas", "sur", "avec", "du"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "en", "por", "para", "una", "con", "del", "se", "no"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "met", "voor", "zijn", "ook", "maar", "die"},
+}
+
+// DetectLanguage returns the base language code for a post. A declared
+// language (from the item or its channel) wins; otherwise the text is
+// classified by script and, for Latin text, by stopword frequency. It returns
+// "" when no language can be determined.
+func DetectLanguage(declared, text string) string {
+	if lang := NormalizeLanguage(declared); lang != "" {
+		return lang
+	}
+
+	text = strings.ToLower(norm.NFKC.String(text))
+
+	var kana, han, hangul, cyrillic, letters int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	switch {
+	case kana > 0 && (kana+han)*4 >= letters:
+		return "ja"
+	case han*4 >= letters:
+		return "zh"
+	case hangul*4 >= letters:
+		return "ko"
+	case cyrillic*2 >= letters:
+		return "ru"
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) })
+	counts := make(map[string]int, len(words))
+	for _, w := range words {
+		counts[w]++
+	}
+
+	best, bestScore := "", 0
+	for lang, list := range stopwords {
+		score := 0
+		for _, w := range list {
+			score += counts[w]
+		}
+		if score > bestScore || (score == bestScore && score > 0 && lang < best) {
+			best, bestScore = lang, score
+		}
+	}
+	// Require a couple of hits so a single shared word ("die", "de") does not decide.
+	if bestScore < 2 {
+		return ""
+	}
+	return best
+}
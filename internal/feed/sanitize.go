@@ -0,0 +1,379 @@
+package feed
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultIframeHosts are the embed providers whose iframes are kept when no
+// allowlist is configured.
+var DefaultIframeHosts = []string{
+	"youtube.com",
+	"youtube-nocookie.com",
+	"player.vimeo.com",
+}
+
+// Sanitizer strips scripts, event handlers, unsafe URLs, tracking pixels and
+// unapproved iframes from feed HTML. It works from allowlists: elements it
+// does not know are replaced by their children and attributes it does not
+// know are dropped.
+type Sanitizer struct {
+	// IframeHosts lists the hosts whose iframes are kept. A host also
+	// allows its subdomains.
+	IframeHosts []string
+}
+
+// NewSanitizer returns a sanitizer allowing iframes from iframeHosts, or from
+// DefaultIframeHosts when iframeHosts is nil.
+func NewSanitizer(iframeHosts []string) *Sanitizer {
+	if iframeHosts == nil {
+		iframeHosts = DefaultIframeHosts
+	}
+	return &Sanitizer{IframeHosts: iframeHosts}
+}
+
+// droppedElements are removed together with everything inside them.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Param:    true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Base:     true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+}
+
+// globalAttrs are allowed on every element kept.
+var globalAttrs = map[string]bool{"title": true, "lang": true, "dir": true}
+
+// allowedElements maps each element kept to the attributes it may carry on
+// top of globalAttrs.
+var allowedElements = map[atom.Atom]map[string]bool{
+	atom.A:          {"href": true},
+	atom.Abbr:       nil,
+	atom.Audio:      {"src": true, "controls": true},
+	atom.B:          nil,
+	atom.Blockquote: {"cite": true},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Col:        {"span": true},
+	atom.Colgroup:   {"span": true},
+	atom.Dd:         nil,
+	atom.Del:        {"cite": true, "datetime": true},
+	atom.Details:    nil,
+	atom.Dfn:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Iframe:     {"src": true, "width": true, "height": true, "allowfullscreen": true},
+	atom.Img:        {"src": true, "alt": true, "width": true, "height": true},
+	atom.Ins:        {"cite": true, "datetime": true},
+	atom.Kbd:        nil,
+	atom.Li:         {"value": true},
+	atom.Mark:       nil,
+	atom.Ol:         {"start": true, "reversed": true, "type": true},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite": true},
+	atom.S:          nil,
+	atom.Samp:       nil,
+	atom.Small:      nil,
+	atom.Source:     {"src": true, "type": true},
+	atom.Span:       nil,
+	atom.Strike:     nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Summary:    nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan": true, "rowspan": true, "headers": true},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan": true, "rowspan": true, "headers": true, "scope": true},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime": true},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Var:        nil,
+	atom.Video:      {"src": true, "poster": true, "controls": true, "width": true, "height": true},
+}
+
+// urlAttrs are the attributes holding a URL, which is resolved and checked.
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true, "poster": true}
+
+// trackerHosts serve nothing but tracking pixels; images from them are
+// dropped whatever their size.
+var trackerHosts = []string{
+	"pixel.wp.com",
+	"stats.wordpress.com",
+	"pixel.quantserve.com",
+	"google-analytics.com",
+	"feeds.feedburner.com",
+	"feeds.feedblitz.com",
+}
+
+// Sanitize returns src with everything unsafe removed. Relative URLs are
+// resolved against base when it is not nil.
+func (s *Sanitizer) Sanitize(src string, base *url.URL) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), context)
+	if err != nil {
+		return html.EscapeString(src)
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	s.cleanChildren(root, base)
+
+	var b strings.Builder
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (s *Sanitizer) cleanChildren(parent *html.Node, base *url.URL) {
+	var next *html.Node
+	for c := parent.FirstChild; c != nil; c = next {
+		next = c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			if droppedElements[c.DataAtom] || c.Namespace != "" {
+				parent.RemoveChild(c)
+				continue
+			}
+			attrs, ok := allowedElements[c.DataAtom]
+			if !ok {
+				// Unknown elements give way to their content.
+				s.cleanChildren(c, base)
+				for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+					c.RemoveChild(gc)
+					parent.InsertBefore(gc, c)
+				}
+				parent.RemoveChild(c)
+				continue
+			}
+			if !s.cleanElement(c, attrs, base) {
+				parent.RemoveChild(c)
+				continue
+			}
+			s.cleanChildren(c, base)
+		default:
+			// Comments, doctypes and anything else the parser hands back.
+			parent.RemoveChild(c)
+		}
+	}
+}
+
+// cleanElement filters n's attributes and reports whether n should be kept
+// at all.
+func (s *Sanitizer) cleanElement(n *html.Node, allowed map[string]bool, base *url.URL) bool {
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !(globalAttrs[a.Key] || allowed[a.Key]) {
+			continue
+		}
+		if urlAttrs[a.Key] {
+			u, ok := safeURL(a.Val, base, a.Key == "href")
+			if !ok {
+				continue
+			}
+			a.Val = u
+		}
+		kept = append(kept, a)
+	}
+	n.Attr = kept
+
+	switch n.DataAtom {
+	case atom.A:
+		n.Attr = append(n.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+	case atom.Img:
+		src := attr(n, "src")
+		if src == "" || isTrackingPixel(n, src) {
+			return false
+		}
+	case atom.Iframe:
+		if !s.allowedIframe(attr(n, "src")) {
+			return false
+		}
+		// Fallback content is raw text and would be rendered unescaped.
+		for n.FirstChild != nil {
+			n.RemoveChild(n.FirstChild)
+		}
+		n.Attr = append(n.Attr, html.Attribute{Key: "sandbox", Val: "allow-scripts allow-same-origin allow-popups"})
+	}
+	return true
+}
+
+// safeURL resolves raw against base and returns it if it uses a scheme that
+// cannot run code. mailto is only allowed for links.
+func safeURL(raw string, base *url.URL, link bool) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "":
+		return u.String(), true
+	case "mailto":
+		return u.String(), link
+	}
+	return "", false
+}
+
+func (s *Sanitizer) allowedIframe(src string) bool {
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	return matchesHost(u.Hostname(), s.IframeHosts)
+}
+
+// matchesHost reports whether host is one of hosts or a subdomain of one.
+func matchesHost(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && (host == h || strings.HasSuffix(host, "."+h)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrackingPixel(n *html.Node, src string) bool {
+	if u, err := url.Parse(src); err == nil && matchesHost(u.Hostname(), trackerHosts) {
+		return true
+	}
+	w, wok := pixels(attr(n, "width"))
+	h, hok := pixels(attr(n, "height"))
+	return wok && hok && w <= 1 && h <= 1
+}
+
+// pixels parses a width or height attribute such as "1" or "1px".
+func pixels(v string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "px"))
+	return n, err == nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// resolveBase resolves each xml:base in turn, outermost first, against base.
+func resolveBase(base *url.URL, refs ...string) *url.URL {
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			continue
+		}
+		if base == nil {
+			base = u
+		} else {
+			base = base.ResolveReference(u)
+		}
+	}
+	return base
+}
+
+// Sanitize cleans every item's summary and content with s, strips control
+// characters from titles and authors, and makes item, comment and channel
+// links absolute. Relative URLs resolve against feedURL, any xml:base on the
+// document, channel or item, and otherwise the item's own link, which is
+// where its content is published.
+func (f *RSSFeed) Sanitize(feedURL string, s *Sanitizer) {
+	base := resolveBase(nil, feedURL, f.Base, f.Channel.Base)
+	f.Channel.Link = resolveLink(base, f.Channel.Link)
+	f.Channel.Title = stripControl(f.Channel.Title)
+	for i := range f.Channel.Item {
+		item := &f.Channel.Item[i]
+		item.Title = stripControl(item.Title)
+		item.Author = stripControl(item.Author)
+		itemBase := resolveBase(base, item.Base)
+		item.Link = resolveLink(itemBase, item.Link)
+		item.Comments = resolveLink(itemBase, item.Comments)
+		contentBase := itemBase
+		if item.Base == "" && item.Link != "" {
+			contentBase = resolveBase(itemBase, item.Link)
+		}
+		item.Description = stripControl(s.Sanitize(item.Description, contentBase))
+		item.Content = stripControl(s.Sanitize(item.Content, contentBase))
+	}
+}
+
+// stripControl removes control characters other than newline and tab, which
+// would otherwise reach the terminal as escape sequences when a post is
+// listed or searched.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// resolveLink makes a link absolute, dropping it if its scheme is unsafe.
+func resolveLink(base *url.URL, link string) string {
+	if link == "" {
+		return ""
+	}
+	u, ok := safeURL(link, base, false)
+	if !ok {
+		return ""
+	}
+	return u
+}
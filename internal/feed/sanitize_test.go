@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	base := &url.URL{Scheme: "https", Host: "example.com", Path: "/blog/"}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"script", `<p>hi<script>alert(1)</script></p>`, `<p>hi</p>`},
+		{"external script", `<script src="https://evil.example/x.js"></script>`, ``},
+		{"onerror", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png"/>`},
+		{"onload", `<body onload="alert(1)"><p>x</p></body>`, `<p>x</p>`},
+		{"javascript url", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"mixed case javascript url", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"entity-encoded javascript url", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript url with tab", `<a href=" &#x6A;ava&#x09;script:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"data url link", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"data url image", `<img src="data:image/svg+xml;base64,PHN2Zz4=">`, ``},
+		{"svg script", `<svg><script>alert(1)</script></svg><p>after</p>`, `<p>after</p>`},
+		{"svg onload", `<svg onload="alert(1)"><circle r="1"/></svg>`, ``},
+		{"iframe from other host", `<iframe src="https://evil.example/embed"></iframe>`, ``},
+		{"allowed iframe", `<iframe src="https://www.youtube.com/embed/x"><script>alert(1)</script></iframe>`,
+			`<iframe src="https://www.youtube.com/embed/x" sandbox="allow-scripts allow-same-origin allow-popups"></iframe>`},
+		{"1x1 pixel", `<img src="https://example.com/p.gif" width="1" height="1">`, ``},
+		{"tracker host", `<img src="https://pixel.wp.com/g.gif?x=1">`, ``},
+		{"ordinary image", `<img src="https://example.com/photo.jpg" width="640" height="480" alt="a">`,
+			`<img src="https://example.com/photo.jpg" width="640" height="480" alt="a"/>`},
+		{"relative urls", `<a href="/post">x</a><img src="img/a.png">`,
+			`<a href="https://example.com/post" rel="nofollow noopener noreferrer">x</a><img src="https://example.com/blog/img/a.png"/>`},
+	}
+
+	s := NewSanitizer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Sanitize(tt.in, base); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeNestedBase(t *testing.T) {
+	var f RSSFeed
+	f.Base = "https://example.com/"
+	f.Channel.Base = "feeds/"
+	f.Channel.Item = []RSSItem{
+		{
+			Base:        "2025/",
+			Link:        "post.html",
+			Description: `<a href="other.html">x</a><img src="../img/a.png">`,
+		},
+		{
+			Link:        "/posts/two/",
+			Description: `<a href="notes">x</a>`,
+		},
+	}
+	f.Sanitize("https://feeds.example.org/rss", NewSanitizer(nil))
+
+	tests := []struct {
+		got, want string
+	}{
+		{f.Channel.Item[0].Link, "https://example.com/feeds/2025/post.html"},
+		{f.Channel.Item[0].Description, `<a href="https://example.com/feeds/2025/other.html" rel="nofollow noopener noreferrer">x</a><img src="https://example.com/feeds/img/a.png"/>`},
+		{f.Channel.Item[1].Link, "https://example.com/posts/two/"},
+		{f.Channel.Item[1].Description, `<a href="https://example.com/posts/two/notes" rel="nofollow noopener noreferrer">x</a>`},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("case %d: got %q, want %q", i, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeControlCharacters(t *testing.T) {
+	var f RSSFeed
+	f.Channel.Title = "Feed\x1b]0;pwned\x07"
+	f.Channel.Item = []RSSItem{{
+		Title:       "Title\x1b[2J\r",
+		Author:      "Alice\u009b31m",
+		Description: "<p>one\x1b[31m\ttwo\nthree</p>",
+	}}
+	f.Sanitize("https://example.com/rss", NewSanitizer(nil))
+
+	tests := []struct {
+		got, want string
+	}{
+		{f.Channel.Title, "Feed]0;pwned"},
+		{f.Channel.Item[0].Title, "Title[2J"},
+		{f.Channel.Item[0].Author, "Alice31m"},
+		{f.Channel.Item[0].Description, "<p>one[31m\ttwo\nthree</p>"},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("case %d: got %q, want %q", i, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// DuplicateDistance is the largest number of differing simhash bits at which
+// two posts are still considered the same story. Unrelated texts differ in
+// about 32.
+const DuplicateDistance = 7
+
+// minSimhashWords is the least text worth fingerprinting; below it, unrelated
+// short posts would collide.
+const minSimhashWords = 8
+
+// shingleSize is the number of consecutive words hashed together.
+const shingleSize = 2
+
+// Simhash returns a 64-bit fingerprint of a post's title and HTML body such
+// that near-identical texts differ in only a few bits. Title words count
+// twice, as syndicated copies keep the headline but often trim the body. ok
+// is false when there is too little text to fingerprint.
+func Simhash(title, body string) (hash uint64, ok bool) {
+	titleWords := words(title)
+	bodyWords := words(PlainText(body))
+	if len(titleWords)+len(bodyWords) < minSimhashWords {
+		return 0, false
+	}
+
+	var weights [64]int
+	add := func(ws []string, weight int) {
+		for i := 0; i+shingleSize <= len(ws) || (i == 0 && len(ws) > 0); i++ {
+			h := fnv.New64a()
+			h.Write([]byte(strings.Join(ws[i:min(i+shingleSize, len(ws))], " ")))
+			sum := h.Sum64()
+			for b := range weights {
+				if sum&(1<<b) != 0 {
+					weights[b] += weight
+				} else {
+					weights[b] -= weight
+				}
+			}
+		}
+	}
+	add(titleWords, 2)
+	add(bodyWords, 1)
+
+	for b, w := range weights {
+		if w > 0 {
+			hash |= 1 << b
+		}
+	}
+	return hash, true
+}
+
+// Distance is the number of bits in which two simhashes differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// words lowercases text and splits it into runs of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,113 @@
+package feed
+
+import "testing"
+
+const storyTitle = "City council approves new bike lanes on Main Street"
+
+const storyBody = `<p>The city council voted 7 to 2 on Tuesday night to approve a network of protected bike lanes along Main Street, ending a debate that has run for more than two years.</p>
+<p>Supporters said the lanes would make the downtown corridor safer for cyclists and pedestrians, while several shop owners argued that losing parking spaces would hurt business during the construction period.</p>
+<p>Work is expected to begin in the spring and take about eight months, according to the transportation department, which will publish a detailed schedule next month.</p>`
+
+func TestSimhashDuplicates(t *testing.T) {
+	original, ok := Simhash(storyTitle, storyBody)
+	if !ok {
+		t.Fatal("Simhash of the original story: ok = false")
+	}
+
+	tests := []struct {
+		name  string
+		title string
+		body  string
+	}{
+		{"identical", storyTitle, storyBody},
+		{"different markup", storyTitle,
+			`<div class="entry"><p>The city council voted 7 to 2 on <b>Tuesday night</b> to approve a network of protected bike lanes along <a href="https://example.com/main">Main Street</a>, ending a debate that has run for more than two years.</p>
+<p>Supporters said the lanes would make the downtown corridor safer for cyclists and pedestrians, while several shop owners argued that losing parking spaces would hurt business during the construction period.</p>
+<p>Work is expected to begin in the spring and take about eight months, according to the transportation department, which will publish a detailed schedule next month.</p></div>`},
+		{"syndication footer", storyTitle, storyBody +
+			`<p>The post City council approves new bike lanes on Main Street appeared first on Daily Courier.</p>`},
+		{"title case and punctuation", "City Council Approves New Bike Lanes on Main Street!", storyBody},
+		{"light edit", storyTitle, `<p>The city council voted 7 to 2 on Tuesday evening to approve a network of protected bike lanes along Main Street, ending a debate that has run for more than two years.</p>
+<p>Supporters said the lanes would make the downtown corridor safer for cyclists and pedestrians, while several shop owners argued that losing parking spaces would hurt business during the construction period.</p>
+<p>Work is expected to begin in the spring and take about eight months, according to the transportation department, which will publish a detailed schedule next month.</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, ok := Simhash(tt.title, tt.body)
+			if !ok {
+				t.Fatal("ok = false")
+			}
+			if d := Distance(original, h); d > DuplicateDistance {
+				t.Errorf("distance %d, want at most %d", d, DuplicateDistance)
+			}
+		})
+	}
+}
+
+func TestSimhashUnrelated(t *testing.T) {
+	original, _ := Simhash(storyTitle, storyBody)
+
+	tests := []struct {
+		name  string
+		title string
+		body  string
+	}{
+		{"release notes", "Go 1.25 is released",
+			`<p>Today the Go team is happy to announce the release of Go 1.25. You can get it from the download page. This release brings improvements to the toolchain, the runtime and the standard library, and a new experimental garbage collector.</p>`},
+		{"recipe", "A simple weeknight lentil soup",
+			`<p>Soften an onion, two carrots and a stick of celery in olive oil, then add garlic, cumin and a cup of red lentils. Cover with stock, simmer for twenty minutes and finish with lemon juice and plenty of black pepper.</p>`},
+		{"same topic", "Residents debate parking rules downtown",
+			`<p>At a packed meeting on Thursday, residents and shop owners traded arguments over new parking rules for the downtown area, with a final vote on the proposal expected before the end of the year.</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, ok := Simhash(tt.title, tt.body)
+			if !ok {
+				t.Fatal("ok = false")
+			}
+			if d := Distance(original, h); d <= DuplicateDistance {
+				t.Errorf("distance %d, want more than %d", d, DuplicateDistance)
+			}
+		})
+	}
+}
+
+func TestSimhashTooShort(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		body  string
+	}{
+		{"empty", "", ""},
+		{"title only", "Weekly links", ""},
+		{"markup only", "", `<p><img src="https://example.com/a.png"></p>`},
+		{"seven words", "Short note", "<p>see you all next week</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if h, ok := Simhash(tt.title, tt.body); ok {
+				t.Errorf("Simhash(%q, %q) = %x, true; want ok = false", tt.title, tt.body, h)
+			}
+		})
+	}
+	if _, ok := Simhash("Short note", "<p>see you all next week, friends</p>"); !ok {
+		t.Errorf("Simhash of %d words: ok = false, want true", minSimhashWords)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff, 0x0f, 4},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
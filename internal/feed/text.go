@@ -0,0 +1,32 @@
+package feed
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// PlainText returns the text content of an HTML fragment.
+func PlainText(src string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			b.Write(z.Text())
+			b.WriteByte(' ')
+		}
+	}
+}
+
+// Excerpt returns the start of an HTML fragment as plain text with
+// whitespace collapsed, cut to at most n runes with an ellipsis.
+func Excerpt(src string, n int) string {
+	text := []rune(strings.Join(strings.Fields(PlainText(src)), " "))
+	if len(text) <= n {
+		return string(text)
+	}
+	return strings.TrimSpace(string(text[:n-1])) + "…"
+}
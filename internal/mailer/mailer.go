@@ -0,0 +1,208 @@
+// Package mailer builds multipart mail messages and sends them over SMTP.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Numpkens/gatorcli/internal/config"
+)
+
+// timeout bounds connecting to the server and the whole conversation after.
+const timeout = 30 * time.Second
+
+// Message is a mail with a plain-text body and, optionally, an HTML
+// alternative.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Bytes encodes the message as RFC 5322 text.
+func (m Message) Bytes(now time.Time) ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address '%s': %w", m.From, err)
+	}
+	to := make([]string, len(m.To))
+	for i, addr := range m.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address '%s': %w", addr, err)
+		}
+		to[i] = a.String()
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuoted(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+w.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuoted(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuoted writes body quoted-printable encoded, with CRLF line endings.
+func writeQuoted(w io.Writer, body string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qw, body); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// messageID returns a unique Message-ID in the sender's domain.
+func messageID(from string) string {
+	domain := "gator.invalid"
+	if at := strings.LastIndexByte(from, '@'); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// Send delivers a message through the configured server.
+func Send(cfg config.SMTP, m Message) error {
+	if cfg.Host == "" {
+		return errors.New("no SMTP host configured")
+	}
+	if cfg.From != "" && m.From == "" {
+		m.From = cfg.From
+	}
+	body, err := m.Bytes(time.Now())
+	if err != nil {
+		return err
+	}
+	sender, _ := mail.ParseAddress(m.From)
+
+	mode := cfg.TLS
+	if mode == "" {
+		mode = config.TLSStartTLS
+	}
+	port := cfg.Port
+	if port == 0 {
+		switch mode {
+		case config.TLSImplicit:
+			port = 465
+		case config.TLSNone:
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch mode {
+	case config.TLSImplicit:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case config.TLSStartTLS, config.TLSNone:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return fmt.Errorf("unknown SMTP tls mode '%s': expected starttls, tls or none", cfg.TLS)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to talk to %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if mode == config.TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS; set tls to \"tls\" or \"none\"", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost.
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(sender.Address); err != nil {
+		return fmt.Errorf("server rejected sender: %w", err)
+	}
+	for _, addr := range m.To {
+		a, _ := mail.ParseAddress(addr)
+		if err := c.Rcpt(a.Address); err != nil {
+			return fmt.Errorf("server rejected recipient %s: %w", a.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
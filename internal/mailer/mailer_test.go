@@ -0,0 +1,224 @@
+package mailer
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Numpkens/gatorcli/internal/config"
+)
+
+// session is what a stub server was told during one SMTP conversation.
+type session struct {
+	from string
+	to   []string
+	data string
+	err  error
+}
+
+// serveSMTP accepts one connection on l and plays a minimal SMTP server,
+// recording the envelope and message.
+func serveSMTP(l net.Listener, done chan<- session) {
+	var s session
+	defer func() { done <- s }()
+
+	conn, err := l.Accept()
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(line string) { tp.PrintfLine("%s", line) }
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			s.err = err
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250 stub")
+		case "MAIL":
+			s.from = arg
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, arg)
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				s.err = err
+				return
+			}
+			s.data = string(data)
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	done := make(chan session, 1)
+	go serveSMTP(l, done)
+
+	port := l.Addr().(*net.TCPAddr).Port
+	cfg := config.SMTP{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "Gator <gator@example.com>",
+		TLS:  config.TLSNone,
+	}
+	err = Send(cfg, Message{
+		To:      []string{"Alice <alice@example.com>", "bob@example.org"},
+		Subject: "Your digest: 2 new posts",
+		Text:    "Hello Alice,\nTwo posts today — enjoy.\n",
+		HTML:    "<p>Hello Alice,</p><p>Two posts today &mdash; enjoy.</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	s := <-done
+	if s.err != nil {
+		t.Fatalf("stub server: %v", s.err)
+	}
+
+	if s.from != "FROM:<gator@example.com>" {
+		t.Errorf("MAIL %s, want FROM:<gator@example.com>", s.from)
+	}
+	wantTo := []string{"TO:<alice@example.com>", "TO:<bob@example.org>"}
+	if strings.Join(s.to, " ") != strings.Join(wantTo, " ") {
+		t.Errorf("RCPT %q, want %q", s.to, wantTo)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(s.data))
+	if err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	for key, want := range map[string]string{
+		"From":         `"Gator" <gator@example.com>`,
+		"To":           `"Alice" <alice@example.com>, <bob@example.org>`,
+		"MIME-Version": "1.0",
+	} {
+		if got := msg.Header.Get(key); got != want {
+			t.Errorf("%s: %q, want %q", key, got, want)
+		}
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Your digest: 2 new posts" {
+		t.Errorf("Subject: %q (%v)", subject, err)
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID %q is not in the sender's domain", msg.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q (%v), want multipart/alternative", msg.Header.Get("Content-Type"), err)
+	}
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Hello Alice,\nTwo posts today — enjoy.\n"},
+		{"text/html; charset=utf-8", "<p>Hello Alice,</p><p>Two posts today &mdash; enjoy.</p>"},
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for i, want := range parts {
+		p, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if got := p.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part %d Content-Type %q, want %q", i, got, want.contentType)
+		}
+		if got := p.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("part %d Content-Transfer-Encoding %q, want quoted-printable", i, got)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if got := string(body); got != want.body {
+			t.Errorf("part %d body %q, want %q", i, got, want.body)
+		}
+	}
+	if _, err := mr.NextRawPart(); err != io.EOF {
+		t.Errorf("want exactly %d parts, got more (%v)", len(parts), err)
+	}
+}
+
+func TestBytesLineEndings(t *testing.T) {
+	for _, m := range []Message{
+		{From: "gator@example.com", To: []string{"alice@example.com"}, Subject: "s", Text: "one\ntwo\n"},
+		{From: "gator@example.com", To: []string{"alice@example.com"}, Subject: "s", Text: "one\ntwo\n", HTML: "<p>one</p>\n<p>two</p>"},
+	} {
+		b, err := m.Bytes(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(strings.ReplaceAll(string(b), "\r\n", ""), "\n") {
+			t.Errorf("message has bare LF line endings: %q", b)
+		}
+	}
+}
+
+func TestSendRejectedRecipient(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 stub\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "RCPT"):
+				io.WriteString(conn, "550 no such user\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				io.WriteString(conn, "221 bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "250 OK\r\n")
+			}
+		}
+	}()
+
+	err = Send(config.SMTP{
+		Host: "127.0.0.1",
+		Port: l.Addr().(*net.TCPAddr).Port,
+		From: "gator@example.com",
+		TLS:  config.TLSNone,
+	}, Message{To: []string{"nobody@example.com"}, Subject: "x", Text: "x"})
+	if err == nil || !strings.Contains(err.Error(), "nobody@example.com") {
+		t.Errorf("Send = %v, want an error naming the rejected recipient", err)
+	}
+}
@@ -0,0 +1,113 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []Outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a single feed from an OPML document. Category is the text
+// of the enclosing folder outlines joined with "/", or empty at the top level.
+type Subscription struct {
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Category string `json:"category"`
+}
+
+// Parse reads an OPML document and flattens its outlines into subscriptions.
+func Parse(r io.Reader) ([]Subscription, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OPML: %w", err)
+	}
+
+	var subs []Subscription
+	var walk func(outlines []Outline, path []string)
+	walk = func(outlines []Outline, path []string) {
+		for _, o := range outlines {
+			name := strings.TrimSpace(o.Text)
+			if name == "" {
+				name = strings.TrimSpace(o.Title)
+			}
+			if o.XMLURL != "" {
+				subs = append(subs, Subscription{
+					Title:    name,
+					URL:      strings.TrimSpace(o.XMLURL),
+					Category: strings.Join(path, "/"),
+				})
+			}
+			if len(o.Outlines) > 0 {
+				walk(o.Outlines, append(path, name))
+			}
+		}
+	}
+	walk(doc.Body.Outlines, nil)
+
+	return subs, nil
+}
+
+// Write renders subscriptions as an OPML 2.0 document, grouping them into one
+// folder outline per category in the order the categories first appear.
+func Write(w io.Writer, title string, subs []Subscription) error {
+	var doc Document
+	doc.Version = "2.0"
+	doc.Head.Title = title
+	doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+
+	folders := make(map[string]int)
+	for _, sub := range subs {
+		o := Outline{Text: sub.Title, Title: sub.Title, Type: "rss", XMLURL: sub.URL}
+		if sub.Category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, o)
+			continue
+		}
+		i, ok := folders[sub.Category]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			folders[sub.Category] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, Outline{Text: sub.Category, Title: sub.Category})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, o)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write OPML header: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to marshal OPML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		subs []Subscription
+	}{
+		{"uncategorized", []Subscription{
+			{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom"},
+		}},
+		{"one category", []Subscription{
+			{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Tech"},
+			{Title: "LWN", URL: "https://lwn.net/headlines/rss", Category: "Tech"},
+		}},
+		{"nested categories", []Subscription{
+			{Title: "Top", URL: "https://example.com/top.xml"},
+			{Title: "LWN", URL: "https://lwn.net/headlines/rss", Category: "Tech"},
+			{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Tech/Go"},
+			{Title: "Rust Blog", URL: "https://blog.rust-lang.org/feed.xml", Category: "Tech/Rust/Releases"},
+		}},
+		{"escaped names", []Subscription{
+			{Title: `Q&A <weekly> "news"`, URL: "https://example.com/feed?a=1&b=2", Category: "Fun & Games"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, "subscriptions", tt.subs); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			got, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("Parse: %v\n%s", err, buf.String())
+			}
+			if !reflect.DeepEqual(got, tt.subs) {
+				t.Errorf("round trip\n got %+v\nwant %+v", got, tt.subs)
+			}
+		})
+	}
+}
+
+func TestParseNestedOutlines(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<opml version="2.0">
+  <body>
+    <outline text="Tech">
+      <outline text="Go">
+        <outline text="Go Blog" type="rss" xmlUrl=" https://go.dev/blog/feed.atom "/>
+      </outline>
+      <outline title="LWN" type="rss" xmlUrl="https://lwn.net/headlines/rss"/>
+    </outline>
+    <outline text="News">
+      <outline text="BBC" type="rss" xmlUrl="https://feeds.bbci.co.uk/news/rss.xml"/>
+    </outline>
+    <outline text="Empty folder"/>
+  </body>
+</opml>`
+	want := []Subscription{
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Category: "Tech/Go"},
+		{Title: "LWN", URL: "https://lwn.net/headlines/rss", Category: "Tech"},
+		{Title: "BBC", URL: "https://feeds.bbci.co.uk/news/rss.xml", Category: "News"},
+	}
+
+	got, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats accepted by the global --output flag. Text is each command's own
+// human-readable layout; the others are rendered here from json tags.
+const (
+	Text  = "text"
+	JSON  = "json"
+	JSONL = "jsonl"
+	CSV   = "csv"
+	Table = "table"
+)
+
+// ParseFormat validates an --output value.
+func ParseFormat(format string) (string, error) {
+	switch f := strings.ToLower(format); f {
+	case Text, JSON, JSONL, CSV, Table:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format '%s': expected text, json, jsonl, csv or table", format)
+	}
+}
+
+// Write renders v, a struct or slice of structs, in a machine-readable format.
+// An empty slice still produces a CSV or table header, and "[]" for JSON.
+func Write(w io.Writer, format string, v any) error {
+	recs := NewRecords(v)
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(recs)
+	case JSONL:
+		encoder := json.NewEncoder(w)
+		for _, rec := range recs {
+			if err := encoder.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(fieldNames(v)); err != nil {
+			return err
+		}
+		for _, rec := range recs {
+			if err := cw.Write(rec.strings()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case Table:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := fieldNames(v)
+		for i := range header {
+			header[i] = strings.ToUpper(header[i])
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, rec := range recs {
+			fmt.Fprintln(tw, strings.Join(rec.strings(), "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("output format '%s' cannot be written as records", format)
+	}
+}
+
+// Stream writes records one at a time as they are produced, for long-running
+// commands. JSON is streamed as JSON lines; tables need every row up front
+// and are not supported.
+type Stream struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+// NewStream returns a Stream for format.
+func NewStream(w io.Writer, format string) (*Stream, error) {
+	switch format {
+	case JSON, JSONL:
+		return &Stream{format: JSONL, w: w}, nil
+	case CSV:
+		return &Stream{format: CSV, w: w, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("output format '%s' cannot be streamed: use json, jsonl or csv", format)
+	}
+}
+
+// Write emits a single struct as one record.
+func (s *Stream) Write(v any) error {
+	rec := NewRecord(v)
+	if s.format == JSONL {
+		return json.NewEncoder(s.w).Encode(rec)
+	}
+
+	if !s.header {
+		if err := s.csv.Write(fieldNames(v)); err != nil {
+			return err
+		}
+		s.header = true
+	}
+	if err := s.csv.Write(rec.strings()); err != nil {
+		return err
+	}
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+// fieldNames returns the record field names for v's element type, so a
+// header can be written even when there are no rows.
+func fieldNames(v any) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return []string{"value"}
+	}
+	rec := NewRecord(reflect.New(t).Interface())
+	names := make([]string, len(rec))
+	for i, f := range rec {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// strings formats each field for CSV and table output: null is empty, times
+// are RFC 3339 and lists are joined with ";".
+func (r Record) strings() []string {
+	out := make([]string, len(r))
+	for i, f := range r {
+		out[i] = formatValue(f.Value)
+	}
+	return out
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, ";")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Field is a single named value in a Record.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// Record is an ordered set of fields built from a struct's json tags. Unlike
+// encoding a struct directly, sql.Null* and uuid values are unwrapped so they
+// encode as plain values or null, and field order is preserved.
+type Record []Field
+
+// NewRecord converts a struct (or pointer to one) into a Record. Fields without
+// a json tag use the Go field name; fields tagged "-" are skipped.
+func NewRecord(v any) Record {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return Record{{Name: "value", Value: v}}
+	}
+
+	rt := rv.Type()
+	rec := make(Record, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		rec = append(rec, Field{Name: name, Value: plainValue(rv.Field(i).Interface())})
+	}
+	return rec
+}
+
+// NewRecords converts a slice of structs into Records.
+func NewRecords(slice any) []Record {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		return []Record{NewRecord(slice)}
+	}
+	recs := make([]Record, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		recs = append(recs, NewRecord(rv.Index(i).Interface()))
+	}
+	return recs
+}
+
+// plainValue unwraps driver.Valuer types such as sql.NullString and uuid.UUID.
+func plainValue(v any) any {
+	valuer, ok := v.(driver.Valuer)
+	if !ok {
+		return v
+	}
+	value, err := valuer.Value()
+	if err != nil {
+		return v
+	}
+	return value
+}
+
+// MarshalJSON encodes the record as a JSON object with fields in order.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal field %s: %w", f.Name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
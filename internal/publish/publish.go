@@ -0,0 +1,212 @@
+package publish
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Formats accepted by Write.
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+)
+
+// Feed is the channel being published. ID must be a stable URI; Link is the
+// URL the document is served from and may be empty for Atom output.
+type Feed struct {
+	ID      string
+	Title   string
+	Link    string
+	Author  string
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is a single published post. Source names the feed it came from.
+type Entry struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Summary   string    `json:"summary"`
+	Published time.Time `json:"published"`
+	Updated   time.Time `json:"updated"`
+	Source    string    `json:"source"`
+	SourceURL string    `json:"source_url"`
+}
+
+// ContentType returns the MIME type for a format.
+func ContentType(format string) string {
+	if format == FormatRSS {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
+
+// Write renders feed in the given format.
+func Write(w io.Writer, format string, feed Feed) error {
+	var doc any
+	switch format {
+	case FormatAtom:
+		doc = atomDocument(feed)
+	case FormatRSS:
+		if feed.Link == "" {
+			return fmt.Errorf("RSS output requires a link to the published feed")
+		}
+		doc = rssDocument(feed)
+	default:
+		return fmt.Errorf("unknown feed format '%s': expected atom or rss", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed header: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to marshal %s feed: %w", format, err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomSource struct {
+	Title string     `xml:"title"`
+	Links []atomLink `xml:"link,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Summary   *atomText   `xml:"summary,omitempty"`
+	Source    *atomSource `xml:"source,omitempty"`
+}
+
+func atomDocument(feed Feed) atomFeed {
+	doc := atomFeed{
+		ID:      feed.ID,
+		Title:   feed.Title,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Author:  atomPerson{Name: feed.Author},
+	}
+	if feed.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "self", Type: "application/atom+xml", Href: feed.Link})
+	}
+
+	for _, e := range feed.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: e.URL}},
+		}
+		if e.Summary != "" {
+			entry.Summary = &atomText{Type: "html", Body: e.Summary}
+		}
+		if e.Source != "" {
+			entry.Source = &atomSource{Title: e.Source}
+			if e.SourceURL != "" {
+				entry.Source.Links = []atomLink{{Rel: "self", Href: e.SourceURL}}
+			}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description,omitempty"`
+	PubDate     string     `xml:"pubDate"`
+	GUID        rssGUID    `xml:"guid"`
+	Source      *rssSource `xml:"source,omitempty"`
+}
+
+func rssDocument(feed Feed) rssDoc {
+	doc := rssDoc{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Title,
+			LastBuildDate: feed.Updated.UTC().Format(time.RFC1123Z),
+			SelfLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: feed.Link},
+		},
+	}
+
+	for _, e := range feed.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.URL,
+			Description: e.Summary,
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			GUID:        rssGUID{IsPermaLink: "false", Value: e.ID},
+		}
+		// RSS requires a url on <source>, so feeds without one are left out.
+		if e.Source != "" && e.SourceURL != "" {
+			item.Source = &rssSource{URL: e.SourceURL, Name: e.Source}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return doc
+}
@@ -0,0 +1,488 @@
+// Package render lays out post HTML as plain text for a terminal: wrapped
+// paragraphs, headings, lists, quotes, code blocks and tables, with links
+// collected as numbered references at the end.
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/Numpkens/gatorcli/internal/term"
+)
+
+// minWidth keeps deeply nested content readable on narrow terminals.
+const minWidth = 20
+
+// Text renders src, an HTML fragment, as text wrapped to width cells.
+// Control characters are removed, so the result is safe to print to a
+// terminal.
+func Text(src string, width int) string {
+	nodes, err := html.ParseFragment(strings.NewReader(src), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		// The parser only fails on reader errors; fall back to the source.
+		return term.StripControl(src)
+	}
+
+	r := &renderer{width: max(width, minWidth), linkIndex: make(map[string]int)}
+	for _, n := range nodes {
+		r.walk(n)
+	}
+	r.flush()
+
+	if len(r.links) > 0 {
+		r.blankLine()
+		for i, link := range r.links {
+			r.emit(fmt.Sprintf("[%d]: %s", i+1, link))
+		}
+	}
+	return term.StripControl(strings.Join(r.out, "\n"))
+}
+
+// indent is one level of block nesting. first prefixes the first line
+// emitted inside it (a list bullet), rest every line after that.
+type indent struct {
+	first, rest string
+	item        bool
+	used        bool
+}
+
+type renderer struct {
+	width  int
+	out    []string
+	blank  bool
+	inline strings.Builder
+	indent []*indent
+
+	links     []string
+	linkIndex map[string]int
+
+	// cell is set while collecting a table cell, where blocks are flattened
+	// into a single line.
+	cell bool
+}
+
+func (r *renderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.inline.WriteString(collapseSpace(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		r.walkChildren(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Template, atom.Noscript, atom.Head:
+	case atom.Br:
+		if r.cell {
+			r.inline.WriteString(" ")
+		} else {
+			r.inline.WriteString("\n")
+		}
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		r.block(n, strings.Repeat("#", level)+" ")
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main,
+		atom.Aside, atom.Nav, atom.Figure, atom.Figcaption, atom.Address, atom.Details,
+		atom.Summary, atom.Dl, atom.Dt, atom.Center:
+		r.block(n, "")
+	case atom.Dd:
+		r.nested(n, &indent{first: "    ", rest: "    "})
+	case atom.Blockquote:
+		r.nested(n, &indent{first: "> ", rest: "> "})
+	case atom.Ul, atom.Ol, atom.Menu:
+		r.list(n)
+	case atom.Li:
+		// A stray item outside a list.
+		r.nested(n, &indent{first: "• ", rest: "  ", item: true})
+	case atom.Pre:
+		r.pre(n)
+	case atom.Hr:
+		r.flush()
+		r.blankLine()
+		r.emit(strings.Repeat("─", min(r.available(), 40)))
+	case atom.Table:
+		r.table(n)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		r.inline.WriteString("`")
+		r.walkChildren(n)
+		r.inline.WriteString("`")
+	case atom.A:
+		r.walkChildren(n)
+		href := strings.TrimSpace(attr(n, "href"))
+		text := strings.TrimSpace(textContent(n))
+		if href != "" && href != text && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") {
+			fmt.Fprintf(&r.inline, "[%d]", r.reference(href))
+		}
+	case atom.Img:
+		alt := strings.TrimSpace(attr(n, "alt"))
+		label := "[image]"
+		if alt != "" {
+			label = "[image: " + alt + "]"
+		}
+		r.inline.WriteString(label)
+		if src := strings.TrimSpace(attr(n, "src")); src != "" && !strings.HasPrefix(src, "data:") {
+			fmt.Fprintf(&r.inline, "[%d]", r.reference(src))
+		}
+	case atom.Iframe, atom.Video, atom.Audio:
+		if src := strings.TrimSpace(attr(n, "src")); src != "" {
+			fmt.Fprintf(&r.inline, "[%s][%d]", n.Data, r.reference(src))
+		}
+	default:
+		r.walkChildren(n)
+	}
+}
+
+func (r *renderer) walkChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// reference returns the footnote number for a link, reusing the number of an
+// earlier link to the same place.
+func (r *renderer) reference(href string) int {
+	if i, ok := r.linkIndex[href]; ok {
+		return i
+	}
+	r.links = append(r.links, href)
+	r.linkIndex[href] = len(r.links)
+	return len(r.links)
+}
+
+// block renders n as a paragraph of its own, with marker (a heading's "#")
+// before its first line.
+func (r *renderer) block(n *html.Node, marker string) {
+	if r.cell {
+		r.inline.WriteString(" ")
+		r.walkChildren(n)
+		r.inline.WriteString(" ")
+		return
+	}
+	r.flush()
+	if marker == "" {
+		r.walkChildren(n)
+		r.flush()
+		return
+	}
+	r.blankLine()
+	r.indent = append(r.indent, &indent{first: marker, rest: strings.Repeat(" ", len(marker))})
+	r.walkChildren(n)
+	r.flush()
+	r.indent = r.indent[:len(r.indent)-1]
+}
+
+// nested renders n's content indented.
+func (r *renderer) nested(n *html.Node, in *indent) {
+	if r.cell {
+		r.block(n, "")
+		return
+	}
+	r.flush()
+	r.indent = append(r.indent, in)
+	r.walkChildren(n)
+	r.flush()
+	r.indent = r.indent[:len(r.indent)-1]
+}
+
+// list renders the items of a ul or ol with bullets or numbers.
+func (r *renderer) list(n *html.Node) {
+	if r.cell {
+		r.block(n, "")
+		return
+	}
+	r.flush()
+	if !r.inItem() {
+		r.blankLine()
+	}
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			r.walk(c)
+			continue
+		}
+		bullet := "• "
+		if n.DataAtom == atom.Ol {
+			bullet = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		in := &indent{first: bullet, rest: strings.Repeat(" ", term.StringWidth(bullet)), item: true}
+		r.indent = append(r.indent, in)
+		r.walkChildren(c)
+		r.flush()
+		if !in.used {
+			// An empty item still shows its bullet.
+			r.emit("")
+		}
+		r.indent = r.indent[:len(r.indent)-1]
+	}
+	r.flush()
+}
+
+// pre renders preformatted text unwrapped, indented by four spaces.
+func (r *renderer) pre(n *html.Node) {
+	text := textContent(n)
+	if r.cell {
+		r.inline.WriteString(collapseSpace(text))
+		return
+	}
+	r.flush()
+	r.blankLine()
+	text = strings.TrimPrefix(text, "\n")
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), " \t\n")
+	for _, line := range strings.Split(text, "\n") {
+		r.emit("    " + strings.ReplaceAll(line, "\t", "    "))
+	}
+	r.blankLine()
+}
+
+// table renders a table with aligned columns when it fits, and as a list of
+// "header: value" lines per row when it does not.
+func (r *renderer) table(n *html.Node) {
+	if r.cell {
+		r.block(n, "")
+		return
+	}
+	r.flush()
+
+	var rows [][]string
+	header := false
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						row = append(row, r.cellText(cell))
+						if cell.DataAtom == atom.Th && len(rows) == 0 {
+							header = true
+						}
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			case atom.Caption:
+				r.block(c, "")
+			case atom.Table:
+				// Tables nested in cells are flattened by cellText; one
+				// directly inside another is not valid HTML.
+			default:
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], term.StringWidth(cell))
+		}
+	}
+	total := 3 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+
+	r.blankLine()
+	if total > r.available() {
+		r.stackedTable(rows, header)
+		r.blankLine()
+		return
+	}
+	for i, row := range rows {
+		cells := make([]string, len(widths))
+		for j := range widths {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+			cells[j] = cell + strings.Repeat(" ", widths[j]-term.StringWidth(cell))
+		}
+		r.emit(strings.TrimRight(strings.Join(cells, " │ "), " "))
+		if i == 0 && header {
+			rules := make([]string, len(widths))
+			for j, w := range widths {
+				rules[j] = strings.Repeat("─", w)
+			}
+			r.emit(strings.Join(rules, "─┼─"))
+		}
+	}
+	r.blankLine()
+}
+
+func (r *renderer) stackedTable(rows [][]string, header bool) {
+	var names []string
+	if header {
+		names, rows = rows[0], rows[1:]
+	}
+	for i, row := range rows {
+		if i > 0 {
+			r.blankLine()
+		}
+		for j, cell := range row {
+			if j < len(names) && names[j] != "" {
+				cell = names[j] + ": " + cell
+			}
+			r.inline.WriteString(cell)
+			r.flushWith(&indent{first: "", rest: "  "})
+		}
+	}
+}
+
+// cellText renders a table cell's content as a single line.
+func (r *renderer) cellText(n *html.Node) string {
+	saved := r.inline.String()
+	r.inline.Reset()
+	r.cell = true
+	r.walkChildren(n)
+	r.cell = false
+	text := strings.TrimSpace(collapseSpace(r.inline.String()))
+	r.inline.Reset()
+	r.inline.WriteString(saved)
+	return text
+}
+
+// flush wraps and emits the pending inline text as one paragraph.
+func (r *renderer) flush() {
+	r.flushWith(nil)
+}
+
+func (r *renderer) flushWith(extra *indent) {
+	text := strings.TrimSpace(r.inline.String())
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+	if extra != nil {
+		r.indent = append(r.indent, extra)
+		defer func() { r.indent = r.indent[:len(r.indent)-1] }()
+	}
+
+	// Paragraphs are separated by a blank line, except at the start of a
+	// list item or table row.
+	if n := len(r.indent); n == 0 || r.indent[n-1].used || r.indent[n-1].first == r.indent[n-1].rest {
+		r.blankLine()
+	}
+	for _, line := range term.Wrap(text, r.available()) {
+		r.emit(strings.TrimSpace(line))
+	}
+}
+
+// inItem reports whether the innermost block is a list item.
+func (r *renderer) inItem() bool {
+	n := len(r.indent)
+	return n > 0 && r.indent[n-1].item
+}
+
+// available is the width left for text inside the current indentation.
+func (r *renderer) available() int {
+	w := r.width
+	for _, in := range r.indent {
+		w -= max(term.StringWidth(in.first), term.StringWidth(in.rest))
+	}
+	return max(w, minWidth)
+}
+
+// emit appends a line with the current indentation.
+func (r *renderer) emit(line string) {
+	var prefix strings.Builder
+	for _, in := range r.indent {
+		if in.used {
+			prefix.WriteString(in.rest)
+		} else {
+			prefix.WriteString(in.first)
+			in.used = true
+		}
+	}
+	r.out = append(r.out, strings.TrimRight(prefix.String()+line, " "))
+	r.blank = false
+}
+
+// blankLine separates blocks. It is skipped at the start of the output and
+// after another blank line, and keeps a quote's marker.
+func (r *renderer) blankLine() {
+	if len(r.out) == 0 || r.blank {
+		return
+	}
+	var prefix strings.Builder
+	for _, in := range r.indent {
+		if in.used {
+			prefix.WriteString(in.rest)
+		}
+	}
+	r.out = append(r.out, strings.TrimRight(prefix.String(), " "))
+	r.blank = true
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent returns all the text inside n, as written.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Br {
+			b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// collapseSpace replaces each run of whitespace with a single space, as HTML
+// does outside preformatted text.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		default:
+			space = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
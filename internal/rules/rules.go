@@ -0,0 +1,179 @@
+// Package rules matches posts against the per-user rules that act on them as
+// they arrive.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/feed"
+)
+
+// Actions a rule can take on a matching post.
+const (
+	ActionRead   = "read"
+	ActionStar   = "star"
+	ActionTag    = "tag"
+	ActionHide   = "hide"
+	ActionNotify = "notify"
+)
+
+// Actions lists every rule action.
+var Actions = []string{ActionRead, ActionStar, ActionTag, ActionHide, ActionNotify}
+
+// Parts of a post a rule's pattern and keywords are matched against.
+const (
+	ScopeAny     = "any"
+	ScopeTitle   = "title"
+	ScopeContent = "content"
+)
+
+// Scopes lists every match scope.
+var Scopes = []string{ScopeAny, ScopeTitle, ScopeContent}
+
+// ErrNoCondition is returned by Compile for a condition with nothing set,
+// which would match every post.
+var ErrNoCondition = errors.New("a rule needs at least one condition")
+
+// ValidAction reports whether action is one of the known rule actions.
+func ValidAction(action string) bool {
+	for _, a := range Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidScope reports whether scope is one of the known match scopes.
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition is what a post must look like for a rule to fire. Every field
+// that is set must match.
+type Condition struct {
+	// Pattern is a regular expression, matched case-insensitively.
+	Pattern string
+	// Keywords match when any of them appears as a whole word, ignoring case.
+	Keywords []string
+	// Scope is where Pattern and Keywords are looked for; empty means ScopeAny.
+	Scope string
+	// FeedID limits the rule to one feed.
+	FeedID uuid.NullUUID
+	// Author matches when it appears anywhere in the post's author, ignoring case.
+	Author string
+	// Category is the category the rule's owner follows the post's feed in.
+	Category string
+	// Language is a normalized language code such as en.
+	Language string
+}
+
+// Post is the part of a post a rule looks at.
+type Post struct {
+	Title string
+	// Body is the post's HTML content or summary.
+	Body     string
+	FeedID   uuid.UUID
+	Author   string
+	Category string
+	Language string
+}
+
+// Matcher is a compiled Condition.
+type Matcher struct {
+	cond     Condition
+	pattern  *regexp.Regexp
+	keywords *regexp.Regexp
+}
+
+// Compile checks a condition and prepares it for matching.
+func Compile(cond Condition) (*Matcher, error) {
+	if cond.Scope == "" {
+		cond.Scope = ScopeAny
+	}
+	if !ValidScope(cond.Scope) {
+		return nil, fmt.Errorf("invalid scope '%s': expected %s", cond.Scope, strings.Join(Scopes, ", "))
+	}
+	if cond.Pattern == "" && len(cond.Keywords) == 0 && !cond.FeedID.Valid &&
+		cond.Author == "" && cond.Category == "" && cond.Language == "" {
+		return nil, ErrNoCondition
+	}
+
+	m := &Matcher{cond: cond}
+	if cond.Pattern != "" {
+		re, err := regexp.Compile("(?i)" + cond.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		m.pattern = re
+	}
+	if len(cond.Keywords) > 0 {
+		quoted := make([]string, len(cond.Keywords))
+		for i, k := range cond.Keywords {
+			quoted[i] = regexp.QuoteMeta(k)
+		}
+		// \b only knows ASCII word characters, so spell out the boundary.
+		m.keywords = regexp.MustCompile(`(?i)(?:^|[^\pL\pN_])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\pL\pN_])`)
+	}
+	return m, nil
+}
+
+// Match reports whether a post meets every part of the condition.
+func (m *Matcher) Match(p Post) bool {
+	c := m.cond
+	if c.FeedID.Valid && c.FeedID.UUID != p.FeedID {
+		return false
+	}
+	if c.Author != "" && !strings.Contains(strings.ToLower(p.Author), strings.ToLower(c.Author)) {
+		return false
+	}
+	if c.Category != "" && !strings.EqualFold(c.Category, p.Category) {
+		return false
+	}
+	if c.Language != "" && c.Language != p.Language {
+		return false
+	}
+	if m.pattern == nil && m.keywords == nil {
+		return true
+	}
+
+	var texts []string
+	if c.Scope != ScopeContent {
+		texts = append(texts, p.Title)
+	}
+	if c.Scope != ScopeTitle {
+		texts = append(texts, feed.PlainText(p.Body))
+	}
+	return (m.pattern == nil || anyMatch(m.pattern, texts)) &&
+		(m.keywords == nil || anyMatch(m.keywords, texts))
+}
+
+func anyMatch(re *regexp.Regexp, texts []string) bool {
+	for _, t := range texts {
+		if re.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKeywords splits a comma-separated keyword list, dropping blanks.
+func ParseKeywords(list string) []string {
+	var keywords []string
+	for _, k := range strings.Split(list, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
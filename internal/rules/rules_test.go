@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMatch(t *testing.T) {
+	feedA := uuid.MustParse("6f1c7a52-4f55-4c64-9a0d-2a1c0b4f7e01")
+	feedB := uuid.MustParse("0b7d3e1a-9c2f-4d8e-8f6a-5e4d3c2b1a00")
+	post := Post{
+		Title:    "Sponsored: the best VPN of 2025",
+		Body:     `<p>This post is brought to you by <b>AcmeVPN</b>. Try go-kart racing_events in Zürich.</p>`,
+		FeedID:   feedA,
+		Author:   "Jane Doe <jane@example.com>",
+		Category: "Tech",
+		Language: "en",
+	}
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{"keyword in title", Condition{Keywords: []string{"sponsored"}}, true},
+		{"keyword ignores case", Condition{Keywords: []string{"SPONSORED"}}, true},
+		{"any keyword", Condition{Keywords: []string{"nothing", "vpn"}}, true},
+		{"keyword inside a word", Condition{Keywords: []string{"spons"}}, false},
+		{"keyword before a colon", Condition{Keywords: []string{"sponsored"}, Scope: ScopeTitle}, true},
+		{"keyword at end of text", Condition{Keywords: []string{"2025"}}, true},
+		{"keyword in markup only", Condition{Keywords: []string{"b"}, Scope: ScopeContent}, false},
+		{"keyword across html tags", Condition{Keywords: []string{"acmevpn"}}, true},
+		{"keyword before hyphen", Condition{Keywords: []string{"go"}}, true},
+		{"underscore joins words", Condition{Keywords: []string{"racing"}}, false},
+		{"keyword with underscore", Condition{Keywords: []string{"racing_events"}}, true},
+		{"non-ascii letter joins words", Condition{Keywords: []string{"z"}}, false},
+		{"non-ascii keyword", Condition{Keywords: []string{"zürich"}}, true},
+		{"keyword metacharacters quoted", Condition{Keywords: []string{"v.n"}}, false},
+		{"title scope skips body", Condition{Keywords: []string{"acmevpn"}, Scope: ScopeTitle}, false},
+		{"content scope skips title", Condition{Keywords: []string{"sponsored"}, Scope: ScopeContent}, false},
+		{"content scope", Condition{Keywords: []string{"acmevpn"}, Scope: ScopeContent}, true},
+		{"pattern", Condition{Pattern: `^sponsored:`}, true},
+		{"pattern ignores case", Condition{Pattern: `best vpn`}, true},
+		{"pattern in title scope", Condition{Pattern: `brought to you`, Scope: ScopeTitle}, false},
+		{"pattern and keyword", Condition{Pattern: `vpn`, Keywords: []string{"racing"}}, false},
+		{"feed", Condition{FeedID: uuid.NullUUID{UUID: feedA, Valid: true}}, true},
+		{"other feed", Condition{FeedID: uuid.NullUUID{UUID: feedB, Valid: true}}, false},
+		{"author substring", Condition{Author: "jane"}, true},
+		{"other author", Condition{Author: "john"}, false},
+		{"category ignores case", Condition{Category: "tech"}, true},
+		{"other category", Condition{Category: "news"}, false},
+		{"language", Condition{Language: "en"}, true},
+		{"other language", Condition{Language: "de"}, false},
+		{"every part must match", Condition{Keywords: []string{"sponsored"}, Language: "de"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Compile(tt.cond)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := m.Match(post); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cond Condition
+		want error
+	}{
+		{"no condition", Condition{}, ErrNoCondition},
+		{"scope alone", Condition{Scope: ScopeTitle}, ErrNoCondition},
+		{"empty keyword list", Condition{Keywords: []string{}}, ErrNoCondition},
+		{"bad scope", Condition{Keywords: []string{"x"}, Scope: "author"}, nil},
+		{"bad pattern", Condition{Pattern: `(unclosed`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.cond)
+			if err == nil {
+				t.Fatal("Compile succeeded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Compile error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeywords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"sponsored", []string{"sponsored"}},
+		{" sponsored , ad,, promoted ", []string{"sponsored", "ad", "promoted"}},
+		{" , ", nil},
+	}
+	for _, tt := range tests {
+		got := ParseKeywords(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseKeywords(%q) = %q, want %q", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseKeywords(%q) = %q, want %q", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package term
+
+// notifyResize does nothing where there is no SIGWINCH; the screen keeps its
+// size until the next key press redraws it.
+func notifyResize(events chan<- Key) {}
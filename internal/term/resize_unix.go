@@ -0,0 +1,23 @@
+//go:build unix
+
+package term
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize sends a KeyResize event whenever the window changes size.
+func notifyResize(events chan<- Key) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+	go func() {
+		for range sigs {
+			select {
+			case events <- Key{Code: KeyResize}:
+			default:
+			}
+		}
+	}()
+}
@@ -0,0 +1,407 @@
+// Package term drives a full-screen terminal: raw keyboard input, the
+// alternate screen and a cell canvas that is redrawn as a whole.
+package term
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	xterm "golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+// Terminal is the controlling terminal in raw mode on the alternate screen.
+type Terminal struct {
+	in      *os.File
+	out     *bufio.Writer
+	restore func() error
+	events  chan Key
+}
+
+// Open switches stdin to raw mode and stdout to the alternate screen. Close
+// must be called to put the terminal back.
+func Open() (*Terminal, error) {
+	if !IsTerminal(os.Stdin) || !IsTerminal(os.Stdout) {
+		return nil, errors.New("standard input and output must be a terminal")
+	}
+	fd := int(os.Stdin.Fd())
+	old, err := xterm.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	restore := func() error { return xterm.Restore(fd, old) }
+
+	t := &Terminal{
+		in:      os.Stdin,
+		out:     bufio.NewWriterSize(os.Stdout, 64*1024),
+		restore: restore,
+		events:  make(chan Key, 16),
+	}
+	// Alternate screen, hidden cursor.
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	if err := t.out.Flush(); err != nil {
+		restore()
+		return nil, err
+	}
+
+	go t.readKeys()
+	notifyResize(t.events)
+	return t, nil
+}
+
+// Close leaves the alternate screen and restores the terminal mode.
+func (t *Terminal) Close() error {
+	t.out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	flushErr := t.out.Flush()
+	if err := t.restore(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// Size returns the terminal's width and height in cells.
+func (t *Terminal) Size() (int, int) {
+	return Size(os.Stdout)
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return xterm.IsTerminal(int(f.Fd()))
+}
+
+// Size returns the width and height of the terminal f, or 80x24 when f is not
+// a terminal.
+func Size(f *os.File) (int, int) {
+	w, h, err := xterm.GetSize(int(f.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// Events delivers key presses and resizes.
+func (t *Terminal) Events() <-chan Key {
+	return t.events
+}
+
+// Draw replaces the screen with the canvas.
+func (t *Terminal) Draw(c *Canvas) error {
+	t.out.WriteString("\x1b[H")
+	for y := 0; y < c.height; y++ {
+		fmt.Fprintf(t.out, "\x1b[%d;1H", y+1)
+		style := Style(0)
+		t.out.WriteString("\x1b[0m")
+		for x := 0; x < c.width; x++ {
+			cell := c.cells[y*c.width+x]
+			if cell.r == 0 {
+				continue
+			}
+			if cell.style != style {
+				t.out.WriteString(cell.style.sgr())
+				style = cell.style
+			}
+			t.out.WriteRune(cell.r)
+		}
+	}
+	t.out.WriteString("\x1b[0m")
+	return t.out.Flush()
+}
+
+func (t *Terminal) readKeys() {
+	buf := make([]byte, 256)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			close(t.events)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			t.events <- k
+		}
+	}
+}
+
+// KeyCode identifies a key that is not a printable character.
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyTab
+	KeyBacktab
+	KeyBackspace
+	KeyEsc
+	KeyCtrlC
+	KeyCtrlD
+	KeyCtrlU
+	KeyCtrlL
+	KeyResize
+)
+
+// Key is one key press. Rune is set when Code is KeyRune.
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+var escapeKeys = map[string]KeyCode{
+	"[A": KeyUp, "OA": KeyUp,
+	"[B": KeyDown, "OB": KeyDown,
+	"[C": KeyRight, "OC": KeyRight,
+	"[D": KeyLeft, "OD": KeyLeft,
+	"[H": KeyHome, "OH": KeyHome, "[1~": KeyHome, "[7~": KeyHome,
+	"[F": KeyEnd, "OF": KeyEnd, "[4~": KeyEnd, "[8~": KeyEnd,
+	"[5~": KeyPageUp,
+	"[6~": KeyPageDown,
+	"[Z":  KeyBacktab,
+}
+
+var controlKeys = map[byte]KeyCode{
+	'\r':   KeyEnter,
+	'\n':   KeyEnter,
+	'\t':   KeyTab,
+	0x7f:   KeyBackspace,
+	0x08:   KeyBackspace,
+	0x03:   KeyCtrlC,
+	0x04:   KeyCtrlD,
+	0x15:   KeyCtrlU,
+	0x0c:   KeyCtrlL,
+	'\x1b': KeyEsc,
+}
+
+// parseKeys splits one read from the terminal into key presses. Unknown
+// escape sequences are dropped.
+func parseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		if b[0] == '\x1b' && len(b) > 1 {
+			end := 2
+			if b[1] == '[' || b[1] == 'O' {
+				for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+					end++
+				}
+				end = min(end+1, len(b))
+			}
+			if code, ok := escapeKeys[string(b[1:end])]; ok {
+				keys = append(keys, Key{Code: code})
+			}
+			b = b[end:]
+			continue
+		}
+		if code, ok := controlKeys[b[0]]; ok {
+			keys = append(keys, Key{Code: code})
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if r >= ' ' {
+			keys = append(keys, Key{Code: KeyRune, Rune: r})
+		}
+		b = b[size:]
+	}
+	return keys
+}
+
+// Style is a set of text attributes.
+type Style uint8
+
+const (
+	Bold Style = 1 << iota
+	Dim
+	Italic
+	Underline
+	Reverse
+)
+
+func (s Style) sgr() string {
+	codes := []string{"0"}
+	for _, a := range []struct {
+		style Style
+		code  string
+	}{{Bold, "1"}, {Dim, "2"}, {Italic, "3"}, {Underline, "4"}, {Reverse, "7"}} {
+		if s&a.style != 0 {
+			codes = append(codes, a.code)
+		}
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+type cell struct {
+	r     rune
+	style Style
+}
+
+// Canvas is a grid of styled cells. A wide character takes two cells; the
+// second holds no rune.
+type Canvas struct {
+	width, height int
+	cells         []cell
+}
+
+// NewCanvas returns a blank canvas.
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{width: width, height: height, cells: make([]cell, width*height)}
+	c.Fill(0, 0, width, height, 0)
+	return c
+}
+
+// Size returns the canvas dimensions.
+func (c *Canvas) Size() (int, int) {
+	return c.width, c.height
+}
+
+// Fill blanks a rectangle with the given style.
+func (c *Canvas) Fill(x, y, w, h int, style Style) {
+	for row := max(y, 0); row < min(y+h, c.height); row++ {
+		for col := max(x, 0); col < min(x+w, c.width); col++ {
+			c.cells[row*c.width+col] = cell{r: ' ', style: style}
+		}
+	}
+}
+
+// Text writes s at (x, y), clipped to w cells, and returns the number of
+// cells used.
+func (c *Canvas) Text(x, y, w int, style Style, s string) int {
+	if y < 0 || y >= c.height {
+		return 0
+	}
+	used := 0
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			r = ' '
+		}
+		rw := RuneWidth(r)
+		if rw == 0 {
+			continue
+		}
+		if used+rw > w || x+used+rw > c.width {
+			break
+		}
+		i := y*c.width + x + used
+		c.cells[i] = cell{r: r, style: style}
+		if rw == 2 {
+			c.cells[i+1] = cell{style: style}
+		}
+		used += rw
+	}
+	return used
+}
+
+// StripControl removes C0 and C1 control characters other than newline and
+// tab from s, so that text from a feed cannot send escape sequences to the
+// terminal.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// RuneWidth is the number of cells r occupies.
+func RuneWidth(r rune) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// StringWidth is the number of cells s occupies.
+func StringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// Truncate shortens s to at most w cells, ending it with an ellipsis when
+// anything was cut.
+func Truncate(s string, w int) string {
+	if StringWidth(s) <= w {
+		return s
+	}
+	if w <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		rw := RuneWidth(r)
+		if used+rw > w-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += rw
+	}
+	b.WriteRune('…')
+	return b.String()
+}
+
+// Wrap breaks text into lines of at most w cells, at spaces where possible.
+// Existing line breaks are kept.
+func Wrap(text string, w int) []string {
+	if w <= 0 {
+		return nil
+	}
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line, lineWidth := "", 0
+		for _, word := range strings.Fields(para) {
+			ww := StringWidth(word)
+			for ww > w {
+				// A word longer than the line is broken wherever it must be.
+				if line != "" {
+					lines = append(lines, line)
+					line, lineWidth = "", 0
+				}
+				head, rest := splitAt(word, w)
+				lines = append(lines, head)
+				word, ww = rest, StringWidth(rest)
+			}
+			switch {
+			case line == "":
+				line, lineWidth = word, ww
+			case lineWidth+1+ww <= w:
+				line += " " + word
+				lineWidth += 1 + ww
+			default:
+				lines = append(lines, line)
+				line, lineWidth = word, ww
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitAt splits s after at most w cells.
+func splitAt(s string, w int) (string, string) {
+	used := 0
+	for i, r := range s {
+		rw := RuneWidth(r)
+		if used+rw > w {
+			return s[:i], s[i:]
+		}
+		used += rw
+	}
+	return s, ""
+}
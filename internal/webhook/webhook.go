@@ -0,0 +1,142 @@
+// Package webhook builds, signs and sends the JSON payloads gator POSTs to
+// webhooks when new posts arrive.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/feed"
+)
+
+// Headers sent with every delivery. The signature is "sha256=" followed by
+// the hex HMAC-SHA256 of the request body, keyed with the webhook's secret.
+const (
+	SignatureHeader = "X-Gator-Signature-256"
+	EventHeader     = "X-Gator-Event"
+	DeliveryHeader  = "X-Gator-Delivery"
+)
+
+// EventNewPost is the event sent for each new post.
+const EventNewPost = "post.new"
+
+// MaxAttempts is how many times a delivery is tried before it is given up on.
+const MaxAttempts = 10
+
+// firstRetry and maxRetry bound the wait between attempts, which doubles
+// after each failure: with MaxAttempts tries, a delivery is retried for
+// about eight and a half hours.
+const (
+	firstRetry = time.Minute
+	maxRetry   = 6 * time.Hour
+)
+
+// summaryLength is the most runes of a post's text a payload carries.
+const summaryLength = 500
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Event string `json:"event"`
+	User  string `json:"user"`
+	// Rule is the rule that matched the post, for webhooks tied to one.
+	Rule string `json:"rule,omitempty"`
+	Post Post   `json:"post"`
+}
+
+// Post describes the new post in a Payload.
+type Post struct {
+	ID          uuid.UUID `json:"id"`
+	Seq         int64     `json:"seq"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Summary     string    `json:"summary,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	CommentsURL string    `json:"comments_url,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	Feed        Feed      `json:"feed"`
+}
+
+// Feed is the feed a Post came from.
+type Feed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Summary returns the start of an HTML body as plain text, for a payload.
+func Summary(body string) string {
+	return feed.Excerpt(body, summaryLength)
+}
+
+// ValidURL checks that a webhook URL is an absolute http or https URL.
+func ValidURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL '%s': expected an http or https URL", raw)
+	}
+	return nil
+}
+
+// GenerateSecret returns a new random signing secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value for a body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is how long to wait before retrying a delivery that has failed
+// attempts times.
+func Backoff(attempts int) time.Duration {
+	d := firstRetry
+	for i := 1; i < attempts && d < maxRetry; i++ {
+		d *= 2
+	}
+	return min(d, maxRetry)
+}
+
+// Send POSTs a signed payload to a webhook and returns the response status.
+// Any status other than 2xx is an error; status is 0 when no response came
+// back.
+func Send(ctx context.Context, client *http.Client, hookURL, secret string, deliveryID uuid.UUID, body []byte) (status int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(EventHeader, EventNewPost)
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -17,81 +19,111 @@ import (
 	"github.com/Numpkens/gatorcli/internal/config"
 	"github.com/Numpkens/gatorcli/internal/database"
 	"github.com/Numpkens/gatorcli/internal/feed"
+	"github.com/Numpkens/gatorcli/internal/output"
+	"github.com/Numpkens/gatorcli/internal/term"
 )
 
 type state struct {
 	Config *config.Config
 	DB     *database.Queries
 	DBConn *sql.DB
+	// URLs canonicalizes feed and post URLs with the configured tracking
+	// parameters.
+	URLs *feed.Canonicalizer
+	// Output is the format chosen with the global --output flag.
+	Output string
 }
 
-type command struct {
-	Name string
-	Args []string
-}
-
-type commandHandlerFunc func(s *state, cmd command) error
-
-type commands struct {
-	Handlers map[string]commandHandlerFunc
-}
-
-func (c *commands) register(name string, handler commandHandlerFunc) {
-	c.Handlers[name] = handler
-}
+// withTx runs fn inside a single database transaction, committing if fn
+// returns nil and rolling back otherwise.
+func (s *state) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := s.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-func (c *commands) run(s *state, cmd command) error {
-	handler, ok := c.Handlers[cmd.Name]
-	if !ok {
-		return fmt.Errorf("unknown command: %s", cmd.Name)
+	if err := fn(s.DB.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
-	return handler(s, cmd)
+	return nil
 }
 
 // --- MIDDLEWARE ---
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
-		if s.Config.UserID == "" {
-			return errors.New("user is not logged in. Please run 'gator login <username>' first")
-		}
-
-		userID, err := uuid.Parse(s.Config.UserID)
+		user, err := currentUser(context.Background(), s)
 		if err != nil {
-			return fmt.Errorf("invalid user ID in config: %w", err)
+			return err
 		}
+		return handler(s, cmd, user)
+	}
+}
 
-		user, err := s.DB.GetUserByID(context.Background(), userID)
-		if err != nil {
-			return fmt.Errorf("failed to fetch user from database: %w", err)
-		}
+// currentUser returns the user logged in through the config file.
+func currentUser(ctx context.Context, s *state) (database.User, error) {
+	if s.Config.UserID == "" {
+		return database.User{}, errors.New("user is not logged in. Please run 'gator login <username>' first")
+	}
 
-		return handler(s, cmd, user)
+	userID, err := uuid.Parse(s.Config.UserID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("invalid user ID in config: %w", err)
 	}
+
+	user, err := s.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to fetch user from database: %w", err)
+	}
+	return user, nil
+}
+
+// lookupUser returns the user with the given name, or the logged-in user when
+// name is empty.
+func lookupUser(ctx context.Context, s *state, name string) (database.User, error) {
+	if name == "" {
+		return currentUser(ctx, s)
+	}
+	user, err := s.DB.GetUser(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, fmt.Errorf("user '%s' not found", name)
+		}
+		return database.User{}, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return user, nil
 }
 
 // --- COMMAND HANDLERS ---
 
+// resetResult is the record reset prints for --output.
+type resetResult struct {
+	Reset bool `json:"reset"`
+}
+
 func handlerReset(s *state, cmd command) error {
 	ctx := context.Background()
 	_, err := s.DBConn.ExecContext(ctx, "TRUNCATE TABLE users RESTART IDENTITY CASCADE;")
 	if err != nil {
 		return fmt.Errorf("failed to reset database tables: %w", err)
 	}
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, resetResult{Reset: true})
+	}
 	fmt.Println("Database reset initiated.")
 	return nil
 }
 
 func handlerRegister(s *state, cmd command) error {
-	if len(cmd.Args) == 0 {
-		return errors.New("register command requires a single argument: <username>")
-	}
-
 	username := cmd.Args[0]
 	userID := uuid.New()
 	now := time.Now().UTC()
 
-	_, err := s.DB.CreateUser(context.Background(), database.CreateUserParams{
+	user, err := s.DB.CreateUser(context.Background(), database.CreateUserParams{
 		ID:        userID,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -105,15 +137,14 @@ func handlerRegister(s *state, cmd command) error {
 		return fmt.Errorf("failed to register and set current user: %w", err)
 	}
 
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, user)
+	}
 	fmt.Printf("User %s registered successfully (ID: %s).\n", username, userID.String())
 	return nil
 }
 
 func handlerLogin(s *state, cmd command) error {
-	if len(cmd.Args) == 0 {
-		return errors.New("login command requires a single argument: <username>")
-	}
-
 	username := cmd.Args[0]
 	user, err := s.DB.GetUser(context.Background(), username)
 	if err != nil {
@@ -127,17 +158,32 @@ func handlerLogin(s *state, cmd command) error {
 		return fmt.Errorf("failed to set current user: %w", err)
 	}
 
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, user)
+	}
 	fmt.Printf("Successfully set current user to: %s (ID: %s)\n", username, user.ID.String())
 	return nil
 }
 
-// New aggregation function
-func scrapeFeeds(s *state) {
+// New aggregation function. Progress goes to stderr when saved posts are
+// streamed to stdout as records.
+func scrapeFeeds(s *state, stream *output.Stream) {
+	progress := io.Writer(os.Stdout)
+	if stream != nil {
+		progress = os.Stderr
+	}
+
 	ctx := context.Background()
-	now := time.Now().UTC()
 
-	// 1. Get the next feed to fetch from the DB.
-	feed, err := s.DB.GetNextFeedToFetch(ctx)
+	// Send what this refresh queues for webhooks, and any retries that are
+	// due, even when there is no feed to fetch.
+	defer deliverWebhooks(ctx, s, progress)
+	// Mail any scheduled digests that are due, after this feed's new posts
+	// are in.
+	defer sendDueDigests(ctx, s, progress)
+
+	// Get the next feed to fetch from the DB.
+	dbFeed, err := s.DB.GetNextFeedToFetch(ctx)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// This is normal if there are no feeds in the DB
@@ -147,41 +193,173 @@ func scrapeFeeds(s *state) {
 		return
 	}
 
-	fmt.Printf(">> Fetching feed: %s from %s\n", feed.Name, feed.Url)
+	fmt.Fprintf(progress, ">> Fetching feed: %s from %s\n", dbFeed.Name, dbFeed.Url)
+	saved, err := refreshFeed(ctx, s, dbFeed, progress, stream)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return
+	}
+	fmt.Fprintf(progress, "<< Done with feed (%d new posts).\n", saved)
+}
+
+// refreshFeed fetches one feed now and saves any posts we do not have yet,
+// running the followers' rules on them, queueing them for webhooks and
+// returning how many were new. Saved posts are written to stream when it is
+// set, and listed on progress otherwise.
+func refreshFeed(ctx context.Context, s *state, dbFeed database.Feed, progress io.Writer, stream *output.Stream) (int, error) {
+	now := time.Now().UTC()
 
-	// 2. Mark it as fetched.
-	err = s.DB.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
-		ID:            feed.ID,
-		LastFetchedAt: now,
+	// 1. Mark it as fetched.
+	err := s.DB.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+		ID:            dbFeed.ID,
+		LastFetchedAt: sql.NullTime{Time: now, Valid: true},
 		UpdatedAt:     now,
 	})
 	if err != nil {
-		log.Printf("Error marking feed %s as fetched: %v", feed.Name, err)
+		log.Printf("Error marking feed %s as fetched: %v", dbFeed.Name, err)
 	}
 
-	// 3. Fetch the feed using the URL.
+	// 2. Fetch the feed using the URL.
 	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// NOTE: The feed.FetchFeed function is assumed to be available from the internal/feed package
-	rssFeed, err := feed.FetchFeed(fetchCtx, feed.Url)
+	rssFeed, err := feed.FetchFeed(fetchCtx, dbFeed.Url)
 	if err != nil {
-		log.Printf("Error fetching RSS feed %s (%s): %v", feed.Name, feed.Url, err)
-		return
+		return 0, fmt.Errorf("failed to fetch feed %s (%s): %w", dbFeed.Name, dbFeed.Url, err)
+	}
+	rssFeed.Sanitize(dbFeed.Url, feed.NewSanitizer(s.Config.IframeHosts))
+	// Posts saved before links were canonicalized are keyed by the raw link.
+	rawLinks := make([]string, len(rssFeed.Channel.Item))
+	for i, item := range rssFeed.Channel.Item {
+		rawLinks[i] = item.Link
 	}
+	rssFeed.Canonicalize(s.URLs)
 
-	// 4. Iterate over items and print titles.
-	fmt.Printf("   Successfully fetched %d posts from %s\n", len(rssFeed.Channel.Item), feed.Name)
-	for _, item := range rssFeed.Channel.Item {
-		fmt.Printf("   - %s\n", item.Title)
+	feedRules, err := loadFeedRules(ctx, s.DB, dbFeed.ID)
+	if err != nil {
+		log.Printf("Error loading rules for %s: %v", dbFeed.Name, err)
+	}
+	hooks, err := s.DB.GetWebhooksForFeed(ctx, dbFeed.ID)
+	if err != nil {
+		log.Printf("Error loading webhooks for %s: %v", dbFeed.Name, err)
+	}
+
+	// 3. Save each item as a post. Items we already have are only updated
+	// when they have been edited.
+	fmt.Fprintf(progress, "   Successfully fetched %d posts from %s\n", len(rssFeed.Channel.Item), dbFeed.Name)
+	saved := 0
+	for i, item := range rssFeed.Channel.Item {
+		publishedAt, err := feed.ParseDate(item.PubDate)
+		if err != nil {
+			publishedAt = now
+		}
+
+		declared := item.Language
+		if declared == "" {
+			declared = rssFeed.Channel.Language
+		}
+		lang := feed.DetectLanguage(declared, item.Title+"\n"+item.Body())
+
+		if item.Link != "" && (item.GUID != "" || rawLinks[i] != item.Link) {
+			_, err := s.DB.RekeyLegacyPost(ctx, database.RekeyLegacyPostParams{
+				ItemKey: item.Key(),
+				Guid:    sql.NullString{String: item.GUID, Valid: item.GUID != ""},
+				Url:     item.Link,
+				FeedID:  dbFeed.ID,
+				RawUrl:  rawLinks[i],
+			})
+			if err != nil {
+				log.Printf("Error re-keying post %q: %v", item.Title, err)
+			}
+		}
+
+		hash, hashed := feed.Simhash(item.Title, item.Body())
+		id := uuid.New()
+		post, err := s.DB.UpsertPost(ctx, database.UpsertPostParams{
+			ID:          id,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
+			PublishedAt: publishedAt,
+			FeedID:      dbFeed.ID,
+			Language:    sql.NullString{String: lang, Valid: lang != ""},
+			Content:     sql.NullString{String: item.Content, Valid: item.Content != ""},
+			Author:      sql.NullString{String: item.Author, Valid: item.Author != ""},
+			Guid:        sql.NullString{String: item.GUID, Valid: item.GUID != ""},
+			CommentsUrl: sql.NullString{String: item.Comments, Valid: item.Comments != ""},
+			ItemKey:     item.Key(),
+			Simhash:     sql.NullInt64{Int64: int64(hash), Valid: hashed},
+			StoryID:     id,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // Already saved and unchanged.
+		}
+		if err != nil {
+			log.Printf("Error saving post %q: %v", item.Title, err)
+			continue
+		}
+		if post.ID != id {
+			// An existing post was edited.
+			if s.Config.MarkEditedUnread {
+				if _, err := s.DB.ClearPostReads(ctx, post.ID); err != nil {
+					log.Printf("Error marking edited post %q unread: %v", item.Title, err)
+				}
+			}
+			if stream == nil {
+				fmt.Fprintf(progress, "   ~ %s\n", item.Title)
+			}
+			continue
+		}
+		saved++
+		if hashed {
+			if err := assignStory(ctx, s.DB, post, hash); err != nil {
+				log.Printf("Error matching post %q to a story: %v", item.Title, err)
+			}
+		}
+		matched := runRules(ctx, s.DB, feedRules, post, now, progress)
+		enqueueWebhooks(ctx, s.DB, hooks, dbFeed, post, matched, now)
+		if stream != nil {
+			if err := stream.Write(post); err != nil {
+				log.Printf("Error writing post %q: %v", item.Title, err)
+			}
+			continue
+		}
+		fmt.Fprintf(progress, "   + %s\n", item.Title)
 	}
-	fmt.Println("<< Done with feed.")
+	return saved, nil
 }
 
-func handlerAgg(s *state, cmd command) error {
-	if len(cmd.Args) != 1 {
-		return errors.New("agg command requires a single argument: <time_between_reqs> (e.g., 30s, 1m)")
+// storyWindow is how far apart two posts may have been published and still
+// be the same story.
+const storyWindow = 72 * time.Hour
+
+// assignStory adds a newly saved post to the story of its closest
+// near-duplicate in another feed, if it has one.
+func assignStory(ctx context.Context, q *database.Queries, post database.UpsertPostRow, hash uint64) error {
+	candidates, err := q.GetStoryCandidates(ctx, database.GetStoryCandidatesParams{
+		FeedID:      post.FeedID,
+		WindowStart: post.PublishedAt.Add(-storyWindow),
+		WindowEnd:   post.PublishedAt.Add(storyWindow),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find similar posts: %w", err)
+	}
+
+	story, best := uuid.Nil, feed.DuplicateDistance+1
+	for _, c := range candidates {
+		if d := feed.Distance(hash, uint64(c.Simhash.Int64)); d < best {
+			story, best = c.StoryID, d
+		}
+	}
+	if story == uuid.Nil {
+		return nil
 	}
+	return q.SetPostStory(ctx, database.SetPostStoryParams{StoryID: story, ID: post.ID})
+}
+
+func handlerAgg(s *state, cmd command) error {
 	timeBetweenReqsStr := cmd.Args[0]
 
 	timeBetweenRequests, err := time.ParseDuration(timeBetweenReqsStr)
@@ -189,71 +367,126 @@ func handlerAgg(s *state, cmd command) error {
 		return fmt.Errorf("failed to parse duration string '%s'. Example formats: 1s, 30m, 1h: %w", timeBetweenReqsStr, err)
 	}
 
-	fmt.Printf("Collecting feeds every %s...\n", timeBetweenRequests)
-	fmt.Println("Press Ctrl+C to stop the process.")
+	var stream *output.Stream
+	if s.Output != output.Text {
+		stream, err = output.NewStream(os.Stdout, s.Output)
+		if err != nil {
+			return err
+		}
+	}
+
+	progress := io.Writer(os.Stdout)
+	if stream != nil {
+		progress = os.Stderr
+	}
+	fmt.Fprintf(progress, "Collecting feeds every %s...\n", timeBetweenRequests)
+	fmt.Fprintln(progress, "Press Ctrl+C to stop the process.")
 
 	ticker := time.NewTicker(timeBetweenRequests)
 	defer ticker.Stop()
 
 	// Run immediately
-	scrapeFeeds(s)
+	scrapeFeeds(s, stream)
 
 	// Loop forever, running on every tick
 	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+		scrapeFeeds(s, stream)
 	}
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 2 {
-		return errors.New("addfeed command requires two arguments: <name> <url>")
-	}
 	feedName := cmd.Args[0]
 	feedURL := cmd.Args[1]
 
-	userID := user.ID
-	now := time.Now().UTC()
-	feedID := uuid.New()
-	feedFollowID := uuid.New()
+	newFeed, follow, err := addFeed(context.Background(), s, user.ID, feedName, feedURL)
+	if err != nil {
+		if errors.Is(err, ErrFeedExists) {
+			return fmt.Errorf("%w. Use 'gator follow %s' to follow it", err, feedURL)
+		}
+		return err
+	}
 
-	newFeed, err := s.DB.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        feedID,
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      feedName,
-		Url:       feedURL,
-		UserID:    userID,
-	})
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, newFeed)
+	}
+	fmt.Printf("Successfully added new feed and started following it:\n")
+	fmt.Printf("  ID:        %s\n", newFeed.ID)
+	fmt.Printf("  Name:      %s\n", newFeed.Name)
+	fmt.Printf("  URL:       %s\n", newFeed.Url)
+	fmt.Printf("  User Name: %s\n", follow.UserName)
+	fmt.Printf("  Created At: %s\n", newFeed.CreatedAt)
+	return nil
+}
+
+// addFeed creates a feed owned by the user and follows it in one transaction.
+// The feed is stored under its canonical URL, and its name without control
+// characters, since API clients can set it too.
+func addFeed(ctx context.Context, s *state, userID uuid.UUID, name, feedURL string) (database.Feed, database.GetFeedFollowForUserAndFeedRow, error) {
+	now := time.Now().UTC()
+	name = term.StripControl(name)
+	feedURL, err := s.URLs.Canonicalize(feedURL)
 	if err != nil {
-		return fmt.Errorf("failed to create feed in database: %w", err)
+		return database.Feed{}, database.GetFeedFollowForUserAndFeedRow{}, err
 	}
 
-	_, err = s.DB.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		ID:        feedFollowID,
+	var newFeed database.Feed
+	var follow database.GetFeedFollowForUserAndFeedRow
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		_, err := getFeedByURL(ctx, q, s.URLs, feedURL)
+		if err == nil {
+			return ErrFeedExists
+		}
+		if !errors.Is(err, ErrFeedNotFound) {
+			return err
+		}
+
+		newFeed, err = q.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      name,
+			Url:       feedURL,
+			UserID:    userID,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrFeedExists
+			}
+			return fmt.Errorf("failed to create feed in database: %w", err)
+		}
+
+		follow, err = createFollow(ctx, q, userID, newFeed.ID, sql.NullString{}, now)
+		return err
+	})
+	return newFeed, follow, err
+}
+
+// createFollow creates a follow for the user and feed and returns it joined with
+// the user and feed names. It is meant to run inside a transaction.
+func createFollow(ctx context.Context, q *database.Queries, userID, feedID uuid.UUID, category sql.NullString, now time.Time) (database.GetFeedFollowForUserAndFeedRow, error) {
+	_, err := q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
 		CreatedAt: now,
 		UpdatedAt: now,
 		UserID:    userID,
 		FeedID:    feedID,
+		Category:  category,
 	})
 	if err != nil {
-		log.Printf("Warning: Could not auto-create feed follow: %v", err)
+		if isUniqueViolation(err) {
+			return database.GetFeedFollowForUserAndFeedRow{}, ErrAlreadyFollowing
+		}
+		return database.GetFeedFollowForUserAndFeedRow{}, fmt.Errorf("failed to create feed follow: %w", err)
 	}
 
-	follow, err := s.DB.GetFeedFollowForUserAndFeed(context.Background(), database.GetFeedFollowForUserAndFeedParams{
+	follow, err := q.GetFeedFollowForUserAndFeed(ctx, database.GetFeedFollowForUserAndFeedParams{
 		UserID: userID,
 		FeedID: feedID,
 	})
 	if err != nil {
-		log.Printf("Warning: Failed to fetch feed follow join data for printing: %v", err)
+		return database.GetFeedFollowForUserAndFeedRow{}, fmt.Errorf("failed to fetch follow confirmation data: %w", err)
 	}
-
-	fmt.Printf("Successfully added new feed and started following it:\n")
-	fmt.Printf("  ID:        %s\n", newFeed.ID)
-	fmt.Printf("  Name:      %s\n", newFeed.Name)
-	fmt.Printf("  URL:       %s\n", newFeed.Url)
-	fmt.Printf("  User Name: %s\n", follow.UserName)
-	fmt.Printf("  Created At: %s\n", newFeed.CreatedAt)
-	return nil
+	return follow, nil
 }
 
 func handlerListFeeds(s *state, cmd command) error {
@@ -263,6 +496,10 @@ func handlerListFeeds(s *state, cmd command) error {
 		return fmt.Errorf("failed to fetch feeds: %w", err)
 	}
 
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, feedsWithUsers)
+	}
+
 	if len(feedsWithUsers) == 0 {
 		fmt.Println("No feeds found in the database.")
 		return nil
@@ -280,48 +517,102 @@ func handlerListFeeds(s *state, cmd command) error {
 }
 
 func handlerFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return errors.New("follow command requires a single argument: <url>")
-	}
 	feedURL := cmd.Args[0]
-	userID := user.ID
 
-	feed, err := s.DB.GetFeedByUrl(context.Background(), feedURL)
+	follow, err := followFeed(context.Background(), s, user.ID, feedURL, categoryParam(cmd.String("category")))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, ErrFeedNotFound) {
 			return fmt.Errorf("feed with URL '%s' not found. Please add the feed first using 'gator addfeed'", feedURL)
 		}
-		return fmt.Errorf("failed to look up feed: %w", err)
+		return err
 	}
 
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, follow)
+	}
+	if follow.Category.Valid {
+		fmt.Printf("User %s is now following feed %s in category %s.\n", follow.UserName, follow.FeedName, follow.Category.String)
+		return nil
+	}
+	fmt.Printf("User %s is now following feed %s.\n", follow.UserName, follow.FeedName)
+	return nil
+}
+
+// followFeed follows an existing feed by URL in one transaction.
+func followFeed(ctx context.Context, s *state, userID uuid.UUID, feedURL string, category sql.NullString) (database.GetFeedFollowForUserAndFeedRow, error) {
 	now := time.Now().UTC()
-	feedFollowID := uuid.New()
-	_, err = s.DB.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		ID:        feedFollowID,
-		CreatedAt: now,
-		UpdatedAt: now,
-		UserID:    userID,
-		FeedID:    feed.ID,
-	})
-	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			return errors.New("you are already following this feed")
+
+	var follow database.GetFeedFollowForUserAndFeedRow
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		feed, err := getFeedByURL(ctx, q, s.URLs, feedURL)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to create feed follow: %w", err)
+		follow, err = createFollow(ctx, q, userID, feed.ID, category, now)
+		return err
+	})
+	return follow, err
+}
+
+// categoryParam converts a category name into a nullable column value, with
+// a blank name meaning "uncategorized".
+func categoryParam(name string) sql.NullString {
+	name = strings.TrimSpace(name)
+	return sql.NullString{String: name, Valid: name != ""}
+}
+
+// moveResult is the record move prints for --output.
+type moveResult struct {
+	FeedID   uuid.UUID      `json:"feed_id"`
+	FeedName string         `json:"feed_name"`
+	Category sql.NullString `json:"category"`
+}
+
+func handlerMove(s *state, cmd command, user database.User) error {
+	feedURL := cmd.Args[0]
+	category := sql.NullString{}
+	if len(cmd.Args) == 2 {
+		category = categoryParam(cmd.Args[1])
 	}
 
-	follow, err := s.DB.GetFeedFollowForUserAndFeed(context.Background(), database.GetFeedFollowForUserAndFeedParams{
-		UserID: userID,
-		FeedID: feed.ID,
-	})
+	feed, err := moveFeed(context.Background(), s, user.ID, feedURL, category)
 	if err != nil {
-		return fmt.Errorf("failed to fetch follow confirmation data: %w", err)
+		return err
 	}
 
-	fmt.Printf("User %s is now following feed %s.\n", follow.UserName, follow.FeedName)
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, moveResult{FeedID: feed.ID, FeedName: feed.Name, Category: category})
+	}
+	if category.Valid {
+		fmt.Printf("Moved feed %s to category %s.\n", feed.Name, category.String)
+	} else {
+		fmt.Printf("Removed feed %s from its category.\n", feed.Name)
+	}
 	return nil
 }
 
+// moveFeed sets the category of a followed feed; an invalid category clears it.
+func moveFeed(ctx context.Context, s *state, userID uuid.UUID, feedURL string, category sql.NullString) (database.Feed, error) {
+	feed, err := getFeedByURL(ctx, s.DB, s.URLs, feedURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	updated, err := s.DB.SetFeedFollowCategory(ctx, database.SetFeedFollowCategoryParams{
+		Category:  category,
+		UpdatedAt: time.Now().UTC(),
+		UserID:    userID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("failed to update category: %w", err)
+	}
+	if updated == 0 {
+		return database.Feed{}, ErrNotFollowing
+	}
+	return feed, nil
+}
+
 func handlerFollowing(s *state, cmd command, user database.User) error {
 	userID := user.ID
 	follows, err := s.DB.GetFeedFollowsForUser(context.Background(), userID)
@@ -329,43 +620,66 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("failed to fetch feed follows: %w", err)
 	}
 
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, follows)
+	}
+
 	if len(follows) == 0 {
 		fmt.Println("You are not currently following any feeds.")
 		return nil
 	}
 
+	// Follows come back ordered by category with uncategorized feeds last.
 	fmt.Printf("You are following %d feeds:\n", len(follows))
-	for _, follow := range follows {
-		fmt.Printf("  - %s\n", follow.FeedName)
+	for i, follow := range follows {
+		if i == 0 || follow.Category != follows[i-1].Category {
+			if follow.Category.Valid {
+				fmt.Printf("%s:\n", follow.Category.String)
+			} else {
+				fmt.Println("Uncategorized:")
+			}
+		}
+		fmt.Printf("  - %s (%d unread)\n", follow.FeedName, follow.UnreadCount)
 	}
 	return nil
 }
 
 func handlerUnfollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return errors.New("unfollow command requires a single argument: <url>")
-	}
 	feedURL := cmd.Args[0]
-	userID := user.ID
 
-	feed, err := s.DB.GetFeedByUrl(context.Background(), feedURL)
+	feed, err := unfollowFeed(context.Background(), s, user.ID, feedURL)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, ErrFeedNotFound) {
 			return fmt.Errorf("feed with URL '%s' not found. You can only unfollow existing feeds.", feedURL)
 		}
-		return fmt.Errorf("failed to look up feed: %w", err)
+		return err
 	}
 
-	err = s.DB.DeleteFeedFollow(context.Background(), database.DeleteFeedFollowParams{
+	if s.Output != output.Text {
+		return output.Write(os.Stdout, s.Output, feed)
+	}
+	fmt.Printf("Successfully unfollowed feed: %s\n", feed.Name)
+	return nil
+}
+
+// unfollowFeed removes the user's follow of the feed with the given URL.
+func unfollowFeed(ctx context.Context, s *state, userID uuid.UUID, feedURL string) (database.Feed, error) {
+	feed, err := getFeedByURL(ctx, s.DB, s.URLs, feedURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	deleted, err := s.DB.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
 		UserID: userID,
 		FeedID: feed.ID,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to unfollow feed: %w", err)
+		return database.Feed{}, fmt.Errorf("failed to unfollow feed: %w", err)
 	}
-
-	fmt.Printf("Successfully unfollowed feed: %s\n", feed.Name)
-	return nil
+	if deleted == 0 {
+		return database.Feed{}, ErrNotFollowing
+	}
+	return feed, nil
 }
 
 func main() {
@@ -390,31 +704,92 @@ func main() {
 		Config: &cfg,
 		DB:     dbQueries,
 		DBConn: dbConn,
+		URLs:   feed.NewCanonicalizer(cfg.TrackingParams),
 	}
 
-	cmdRegistry := &commands{Handlers: make(map[string]commandHandlerFunc)}
-	cmdRegistry.register("reset", handlerReset)
-	cmdRegistry.register("register", handlerRegister)
-	cmdRegistry.register("login", handlerLogin)
-	cmdRegistry.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmdRegistry.register("feeds", handlerListFeeds)
-	cmdRegistry.register("follow", middlewareLoggedIn(handlerFollow))
-	cmdRegistry.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	cmdRegistry.register("following", middlewareLoggedIn(handlerFollowing))
-	cmdRegistry.register("agg", handlerAgg)
-
-	args := os.Args
-	if len(args) < 2 {
-		fmt.Fprintln(os.Stderr, "Error: Not enough arguments provided. Command name is required.")
-		os.Exit(1)
+	cmdRegistry := &commands{}
+	registerCommands(cmdRegistry)
+
+	// Commands with raw arguments, like the hidden completion command, see
+	// the command line exactly as typed.
+	args := os.Args[1:]
+	appState.Output = output.Text
+	var spec *commandSpec
+	if len(args) > 0 {
+		spec = cmdRegistry.lookup(strings.ToLower(args[0]))
+	}
+	if spec == nil || !spec.RawArgs {
+		args, appState.Output, err = extractOutputFlag(cmdRegistry, args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	commandName := strings.ToLower(args[1])
-	commandArgs := args[2:]
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		if err := printHelp(os.Stdout, cmdRegistry.specs); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
 
-	currentCommand := command{Name: commandName, Args: commandArgs}
-	if err := cmdRegistry.run(appState, currentCommand); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running command '%s': %v\n", commandName, err)
+	commandName := strings.ToLower(args[0])
+	if err := cmdRegistry.run(appState, commandName, args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// extractOutputFlag removes the global --output (or -o) flag from args and
+// returns the remaining args with the chosen format, which defaults to text.
+// Scanning stops at "--", and the value of one of the command's own flags is
+// passed through even if it reads "-o".
+func extractOutputFlag(registry *commands, args []string) ([]string, string, error) {
+	format := output.Text
+	rest := make([]string, 0, len(args))
+	var spec *commandSpec
+	var fs *flag.FlagSet
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if fs != nil && valueFlag(fs, arg) != nil && i+1 < len(args) {
+			rest = append(rest, arg, args[i+1])
+			i++
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !isOutputFlag(name) {
+			rest = append(rest, arg)
+			if fs == nil && !strings.HasPrefix(arg, "-") {
+				// Follow the command and its subcommands to learn its flags.
+				if spec == nil {
+					spec = registry.lookup(strings.ToLower(arg))
+				} else {
+					spec = spec.subcommand(arg)
+				}
+				if spec == nil || spec.RawArgs {
+					fs = flag.NewFlagSet("", flag.ContinueOnError)
+				} else if len(spec.Subcommands) == 0 {
+					fs = spec.flagSet("")
+				}
+			}
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+				return nil, "", fmt.Errorf("flag %s needs a value: text, json, jsonl, csv or table", name)
+			}
+			i++
+			value = args[i]
+		}
+		f, err := output.ParseFormat(value)
+		if err != nil {
+			return nil, "", err
+		}
+		format = f
+	}
+	return rest, format, nil
+}
@@ -0,0 +1,29 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, created_at, user_id, key_hash, label, scope)
+VALUES (@id, @created_at, @user_id, @key_hash, @label, @scope)
+RETURNING *;
+
+-- name: ListAPIKeysForUser :many
+SELECT id, created_at, label, scope, last_used_at
+FROM api_keys
+WHERE user_id = @user_id
+ORDER BY created_at ASC;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE user_id = @user_id
+  AND (id::text = @ref::text OR label = @ref::text);
+
+-- name: GetUserByAPIKeyHash :one
+SELECT
+    users.id, users.created_at, users.updated_at, users.name,
+    api_keys.id AS api_key_id,
+    api_keys.scope
+FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = @key_hash;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = @last_used_at
+WHERE id = @id;
@@ -0,0 +1,36 @@
+-- name: SetDigestSchedule :one
+INSERT INTO digest_schedules (user_id, created_at, updated_at, email, schedule, timezone)
+VALUES (@user_id, @created_at, @created_at, @email, @schedule, @timezone)
+ON CONFLICT (user_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    email = EXCLUDED.email,
+    schedule = EXCLUDED.schedule,
+    timezone = EXCLUDED.timezone
+RETURNING *;
+
+-- name: GetDigestSchedule :one
+SELECT * FROM digest_schedules
+WHERE user_id = @user_id;
+
+-- name: DeleteDigestSchedule :execrows
+DELETE FROM digest_schedules
+WHERE user_id = @user_id;
+
+-- name: ListDigestSchedules :many
+SELECT digest_schedules.*, users.name AS user_name
+FROM digest_schedules
+JOIN users ON users.id = digest_schedules.user_id
+ORDER BY users.name;
+
+-- name: ClaimDigest :execrows
+-- Records that a digest is being sent, unless another aggregator has done
+-- so since last_sent_at was read.
+UPDATE digest_schedules
+SET last_sent_at = @sent_at::timestamptz
+WHERE user_id = @user_id
+  AND last_sent_at IS NOT DISTINCT FROM @prev_sent_at;
+
+-- name: ResetDigestSent :exec
+UPDATE digest_schedules
+SET last_sent_at = @last_sent_at
+WHERE user_id = @user_id;
@@ -16,8 +16,8 @@ JOIN users ON feeds.user_id = users.id
 ORDER BY feeds.created_at DESC;
 
 -- name: CreateFeedFollow :one
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES (@id, @created_at, @updated_at, @user_id, @feed_id)
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+VALUES (@id, @created_at, @updated_at, @user_id, @feed_id, @category)
 RETURNING *;
 
 -- name: GetFeedFollowForUserAndFeed :one
@@ -34,16 +34,33 @@ WHERE feed_follows.user_id = @user_id
 -- name: GetFeedFollowsForUser :many
 SELECT
     feed_follows.*,
-    feeds.name AS feed_name
+    feeds.name AS feed_name,
+    feeds.url AS feed_url,
+    (
+        SELECT COUNT(*)
+        FROM posts
+        LEFT JOIN post_reads ON post_reads.post_id = posts.id
+            AND post_reads.user_id = feed_follows.user_id
+        WHERE posts.feed_id = feed_follows.feed_id
+          AND post_reads.post_id IS NULL
+    ) AS unread_count
 FROM feed_follows
 JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE feed_follows.user_id = @user_id;
+WHERE feed_follows.user_id = @user_id
+ORDER BY feed_follows.category ASC NULLS LAST, feeds.name ASC;
 
--- name: DeleteFeedFollow :exec
+-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows
 WHERE feed_follows.user_id = @user_id
   AND feed_follows.feed_id = @feed_id;
 
+-- name: SetFeedFollowCategory :execrows
+UPDATE feed_follows
+SET category = @category,
+    updated_at = @updated_at
+WHERE feed_follows.user_id = @user_id
+  AND feed_follows.feed_id = @feed_id;
+
 -- name: MarkFeedFetched :exec
 UPDATE feeds
 SET last_fetched_at = @last_fetched_at,
@@ -55,3 +72,35 @@ SELECT *
 FROM feeds
 ORDER BY last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: ListCategoriesForUser :many
+SELECT DISTINCT feed_follows.category::text AS category
+FROM feed_follows
+WHERE feed_follows.user_id = @user_id
+  AND feed_follows.category IS NOT NULL
+ORDER BY 1;
+
+-- name: ListFeeds :many
+SELECT * FROM feeds
+ORDER BY created_at, id;
+
+-- name: SetFeedUrl :exec
+UPDATE feeds
+SET url = @url, updated_at = @updated_at
+WHERE id = @id;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = @id;
+
+-- name: MoveFeedFollows :execrows
+-- Moves follows to another feed; users already following it keep their own
+-- follow and the moved one is left to be deleted with the old feed.
+UPDATE feed_follows
+SET feed_id = @to_feed_id, updated_at = @updated_at
+WHERE feed_id = @from_feed_id
+  AND NOT EXISTS (
+      SELECT 1 FROM feed_follows AS existing
+      WHERE existing.feed_id = @to_feed_id
+        AND existing.user_id = feed_follows.user_id
+  );
@@ -0,0 +1,427 @@
+-- name: UpsertPost :one
+-- Items are identified within their feed by item_key. A re-fetched item only
+-- touches the stored post when its text has changed, keeping the post's ID,
+-- number and publication date; unchanged items return no row.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, language, content, author, guid, comments_url, item_key, simhash, story_id)
+VALUES (@id, @created_at, @updated_at, @title, @url, @description, @published_at, @feed_id, @language, @content, @author, @guid, @comments_url, @item_key, @simhash, @story_id)
+ON CONFLICT (feed_id, item_key) DO UPDATE SET
+    updated_at = excluded.updated_at,
+    title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    language = excluded.language,
+    content = excluded.content,
+    author = excluded.author,
+    comments_url = excluded.comments_url,
+    simhash = excluded.simhash
+WHERE (posts.title, posts.url, posts.description, posts.content)
+    IS DISTINCT FROM (excluded.title, excluded.url, excluded.description, excluded.content)
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, seq, language, content, author, guid, comments_url;
+
+-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, language, content, author, guid, comments_url FROM posts
+WHERE id = @id;
+
+-- name: GetPostBySeq :one
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.language,
+    posts.content, posts.author, posts.guid, posts.comments_url,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.seq = @seq;
+
+-- name: GetPostsForUser :many
+-- With collapse_stories, a story carried by several followed feeds is listed
+-- once, as its earliest copy among the posts that pass the other filters.
+SELECT
+    id, created_at, updated_at, title, url, description, published_at, feed_id, seq, language,
+    content, author, guid, comments_url, feed_name, feed_url, category, is_read, is_starred, also_in
+FROM (
+    SELECT
+        posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.language,
+        posts.content, posts.author, posts.guid, posts.comments_url,
+        feeds.name AS feed_name,
+        feeds.url AS feed_url,
+        feed_follows.category,
+        (post_reads.read_at IS NOT NULL)::bool AS is_read,
+        (post_stars.starred_at IS NOT NULL)::bool AS is_starred,
+        ARRAY(
+            SELECT DISTINCT alt_feeds.name FROM posts AS alt
+            JOIN feeds AS alt_feeds ON alt_feeds.id = alt.feed_id
+            JOIN feed_follows AS alt_follows ON alt_follows.feed_id = alt.feed_id
+                AND alt_follows.user_id = feed_follows.user_id
+            WHERE alt.story_id = posts.story_id
+              AND alt.feed_id <> posts.feed_id
+        )::text[] AS also_in,
+        row_number() OVER (PARTITION BY posts.story_id ORDER BY posts.seq) AS story_rank
+    FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    JOIN feeds ON feeds.id = posts.feed_id
+    LEFT JOIN post_reads ON post_reads.post_id = posts.id
+        AND post_reads.user_id = feed_follows.user_id
+    LEFT JOIN post_stars ON post_stars.post_id = posts.id
+        AND post_stars.user_id = feed_follows.user_id
+    WHERE feed_follows.user_id = @user_id
+      AND (@include_read::bool OR post_reads.read_at IS NULL)
+      AND (
+          sqlc.narg(tag)::text IS NULL
+          OR EXISTS (
+              SELECT 1 FROM post_tags
+              WHERE post_tags.user_id = feed_follows.user_id
+                AND post_tags.post_id = posts.id
+                AND post_tags.tag = sqlc.narg(tag)
+          )
+          OR EXISTS (
+              SELECT 1 FROM feed_tags
+              WHERE feed_tags.user_id = feed_follows.user_id
+                AND feed_tags.feed_id = posts.feed_id
+                AND feed_tags.tag = sqlc.narg(tag)
+          )
+      )
+      AND (sqlc.narg(category)::text IS NULL OR feed_follows.category = sqlc.narg(category))
+      AND (sqlc.narg(lang)::text IS NULL OR posts.language = sqlc.narg(lang))
+      AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+      AND (sqlc.narg(since)::timestamptz IS NULL OR posts.published_at >= sqlc.narg(since))
+      AND (sqlc.narg(created_since)::timestamptz IS NULL OR posts.created_at >= sqlc.narg(created_since))
+      AND (NOT @starred_only::bool OR post_stars.starred_at IS NOT NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM post_hides
+          WHERE post_hides.user_id = feed_follows.user_id
+            AND post_hides.post_id = posts.id
+      )
+) AS filtered
+WHERE NOT @collapse_stories::bool OR story_rank = 1
+ORDER BY published_at DESC
+LIMIT @lim;
+
+-- name: MarkPostRead :execrows
+-- Marks one post read without the rest of its story, for rules that
+-- matched that copy alone.
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT @user_id::uuid, posts.id, @read_at::timestamptz
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+WHERE posts.seq = @seq
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostsRead :execrows
+-- Reading a post reads its whole story.
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT @user_id::uuid, posts.id, @read_at::timestamptz
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+WHERE posts.story_id IN (
+    SELECT story.story_id FROM posts AS story
+    JOIN feed_follows AS story_follows ON story_follows.feed_id = story.feed_id
+        AND story_follows.user_id = @user_id
+    WHERE story.seq BETWEEN @from_seq AND @to_seq
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostsUnread :execrows
+-- Marking a post unread does so for its whole story.
+DELETE FROM post_reads
+WHERE post_reads.user_id = @user_id
+  AND post_reads.post_id IN (
+      SELECT posts.id FROM posts
+      JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+          AND feed_follows.user_id = @user_id
+      WHERE posts.story_id IN (
+          SELECT story.story_id FROM posts AS story
+          JOIN feed_follows AS story_follows ON story_follows.feed_id = story.feed_id
+              AND story_follows.user_id = @user_id
+          WHERE story.seq BETWEEN @from_seq AND @to_seq
+      )
+  );
+
+-- name: MarkAllPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, @read_at::timestamptz
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(before)::timestamptz IS NULL OR posts.published_at < sqlc.narg(before))
+  AND (
+      sqlc.narg(label)::text IS NULL
+      OR feed_follows.category = sqlc.narg(label)
+      OR EXISTS (
+          SELECT 1 FROM post_tags
+          WHERE post_tags.user_id = feed_follows.user_id
+            AND post_tags.post_id = posts.id
+            AND post_tags.tag = sqlc.narg(label)
+      )
+      OR EXISTS (
+          SELECT 1 FROM feed_tags
+          WHERE feed_tags.user_id = feed_follows.user_id
+            AND feed_tags.feed_id = posts.feed_id
+            AND feed_tags.tag = sqlc.narg(label)
+      )
+  )
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: StarPosts :execrows
+-- Starring does not depend on still following the post's feed, like the
+-- starred list, so a post stays starrable after its feed is unfollowed.
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT @user_id::uuid, posts.id, @starred_at::timestamptz
+FROM posts
+WHERE posts.seq BETWEEN @from_seq AND @to_seq
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPosts :execrows
+DELETE FROM post_stars
+WHERE post_stars.user_id = @user_id
+  AND post_stars.post_id IN (
+      SELECT posts.id FROM posts
+      WHERE posts.seq BETWEEN @from_seq AND @to_seq
+  );
+
+-- name: GetStarredPostsForUser :many
+-- Starred posts are listed regardless of whether the user still follows the feed.
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.language,
+    posts.content, posts.author, posts.guid, posts.comments_url,
+    feeds.name AS feed_name,
+    post_stars.starred_at
+FROM post_stars
+JOIN posts ON posts.id = post_stars.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE post_stars.user_id = @user_id
+ORDER BY post_stars.starred_at DESC;
+
+-- name: SearchPosts :many
+-- The query is parsed once for each text search configuration in use, and
+-- each post is matched against the parse for its own language. Stemming and
+-- exclusions ("-word") follow the post's language, and the match can still
+-- use the search vector index.
+WITH queries AS (
+    SELECT configs.cfg, websearch_to_tsquery(configs.cfg, @query::text) AS q
+    FROM (
+        SELECT DISTINCT gator_ts_config(languages.language) AS cfg
+        FROM (
+            SELECT DISTINCT posts.language FROM posts
+            WHERE sqlc.narg(lang)::text IS NULL OR posts.language = sqlc.narg(lang)
+        ) AS languages
+    ) AS configs
+)
+SELECT
+    posts.id, posts.title, posts.url, posts.published_at, posts.feed_id, posts.seq, posts.language,
+    feeds.name AS feed_name,
+    (post_reads.read_at IS NOT NULL)::bool AS is_read,
+    ts_rank_cd(posts.search_vector, queries.q)::real AS rank,
+    ts_headline(
+        queries.cfg,
+        coalesce(gator_post_body(posts.description, posts.content), posts.title),
+        queries.q,
+        'StartSel=<<, StopSel=>>, MaxFragments=2, MaxWords=20, MinWords=8'
+    )::text AS snippet
+FROM queries
+JOIN posts ON posts.search_vector @@ queries.q
+    AND gator_ts_config(posts.language) = queries.cfg
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id
+    AND post_reads.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg(lang)::text IS NULL OR posts.language = sqlc.narg(lang))
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR posts.published_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR posts.published_at < sqlc.narg(until))
+  AND (sqlc.narg(is_read)::bool IS NULL OR (post_reads.read_at IS NOT NULL) = sqlc.narg(is_read))
+  AND (
+      sqlc.narg(tag)::text IS NULL
+      OR EXISTS (
+          SELECT 1 FROM post_tags
+          WHERE post_tags.user_id = feed_follows.user_id
+            AND post_tags.post_id = posts.id
+            AND post_tags.tag = sqlc.narg(tag)
+      )
+      OR EXISTS (
+          SELECT 1 FROM feed_tags
+          WHERE feed_tags.user_id = feed_follows.user_id
+            AND feed_tags.feed_id = posts.feed_id
+            AND feed_tags.tag = sqlc.narg(tag)
+      )
+  )
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT @lim;
+
+-- name: GetStreamPosts :many
+-- Backs the Google Reader stream endpoints. A label matches either the
+-- follow's category or a tag on the post or its feed. Starred posts stay in
+-- the starred stream, and can be fetched by id, after their feed is unfollowed.
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.seq, posts.language,
+    posts.content, posts.author, posts.guid, posts.comments_url,
+    feeds.name AS feed_name,
+    feeds.url AS feed_url,
+    feed_follows.category,
+    (post_reads.read_at IS NOT NULL)::bool AS is_read,
+    (post_stars.starred_at IS NOT NULL)::bool AS is_starred,
+    ARRAY(
+        SELECT post_tags.tag FROM post_tags
+        WHERE post_tags.user_id = @user_id
+          AND post_tags.post_id = posts.id
+        UNION
+        SELECT feed_tags.tag FROM feed_tags
+        WHERE feed_tags.user_id = @user_id
+          AND feed_tags.feed_id = posts.feed_id
+        ORDER BY 1
+    )::text[] AS tags
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id
+    AND post_reads.user_id = @user_id
+LEFT JOIN post_stars ON post_stars.post_id = posts.id
+    AND post_stars.user_id = @user_id
+WHERE (
+      feed_follows.user_id IS NOT NULL
+      OR (post_stars.starred_at IS NOT NULL AND (@starred_only::bool OR sqlc.narg(seqs)::bigint[] IS NOT NULL))
+  )
+  AND (sqlc.narg(seqs)::bigint[] IS NULL OR posts.seq = ANY(sqlc.narg(seqs)::bigint[]))
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (
+      sqlc.narg(label)::text IS NULL
+      OR feed_follows.category = sqlc.narg(label)
+      OR EXISTS (
+          SELECT 1 FROM post_tags
+          WHERE post_tags.user_id = @user_id
+            AND post_tags.post_id = posts.id
+            AND post_tags.tag = sqlc.narg(label)
+      )
+      OR EXISTS (
+          SELECT 1 FROM feed_tags
+          WHERE feed_tags.user_id = @user_id
+            AND feed_tags.feed_id = posts.feed_id
+            AND feed_tags.tag = sqlc.narg(label)
+      )
+  )
+  AND (NOT @starred_only::bool OR post_stars.starred_at IS NOT NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM post_hides
+      WHERE post_hides.user_id = @user_id
+        AND post_hides.post_id = posts.id
+  )
+  AND (sqlc.narg(is_read)::bool IS NULL OR (post_reads.read_at IS NOT NULL) = sqlc.narg(is_read))
+  AND (sqlc.narg(newer_than)::timestamptz IS NULL OR posts.published_at >= sqlc.narg(newer_than))
+  AND (sqlc.narg(older_than)::timestamptz IS NULL OR posts.published_at < sqlc.narg(older_than))
+ORDER BY
+    CASE WHEN @oldest_first::bool THEN posts.published_at END ASC,
+    posts.published_at DESC
+LIMIT @lim OFFSET @off;
+
+-- name: ClearPostReads :execrows
+-- Marks a post unread again for every user.
+DELETE FROM post_reads
+WHERE post_id = @post_id;
+
+-- name: ListPostIdentities :many
+SELECT id, feed_id, url, item_key FROM posts
+ORDER BY seq;
+
+-- name: SetPostIdentity :exec
+UPDATE posts
+SET feed_id = @feed_id, url = @url, item_key = @item_key
+WHERE id = @id;
+
+-- name: DeletePost :exec
+-- Its reads, stars, hides and tags go with it; move them first to keep them.
+DELETE FROM posts
+WHERE id = @id;
+
+-- name: MovePostReads :execrows
+WITH moved AS (
+    DELETE FROM post_reads
+    WHERE post_id = @from_post_id
+    RETURNING user_id, read_at
+)
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT user_id, @to_post_id::uuid, read_at FROM moved
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MovePostStars :execrows
+WITH moved AS (
+    DELETE FROM post_stars
+    WHERE post_id = @from_post_id
+    RETURNING user_id, starred_at
+)
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT user_id, @to_post_id::uuid, starred_at FROM moved
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetStoryCandidates :many
+-- Posts from other feeds published around the same time, to compare a new
+-- post's simhash against.
+SELECT id, story_id, simhash FROM posts
+WHERE feed_id <> @feed_id
+  AND simhash IS NOT NULL
+  AND published_at BETWEEN @window_start AND @window_end
+ORDER BY published_at;
+
+-- name: SetPostStory :exec
+UPDATE posts
+SET story_id = @story_id
+WHERE id = @id;
+
+-- name: HidePosts :execrows
+INSERT INTO post_hides (user_id, post_id, hidden_at)
+SELECT @user_id::uuid, posts.id, @hidden_at::timestamptz
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+WHERE posts.seq BETWEEN @from_seq AND @to_seq
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MovePostHides :execrows
+WITH moved AS (
+    DELETE FROM post_hides
+    WHERE post_id = @from_post_id
+    RETURNING user_id, hidden_at
+)
+INSERT INTO post_hides (user_id, post_id, hidden_at)
+SELECT user_id, @to_post_id::uuid, hidden_at FROM moved
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetFollowedPostsSince :many
+-- Every post from a user's followed feeds published since a time, read,
+-- hidden or not, newest first. Rules are checked against these.
+SELECT
+    posts.id, posts.title, posts.description, posts.content, posts.author, posts.language,
+    posts.feed_id, posts.seq, posts.published_at,
+    feeds.name AS feed_name,
+    feed_follows.category
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.published_at >= @since
+ORDER BY posts.published_at DESC;
+
+-- name: RekeyLegacyPost :execrows
+-- Posts saved before GUIDs were stored are keyed by the link they were saved
+-- under, which is the raw link unless dedupe has since canonicalized it. This
+-- gives the oldest such post the item's current key and canonical link, so
+-- that the item is recognised instead of being saved a second time. Items
+-- without a GUID are re-keyed from their raw link to their canonical one.
+UPDATE posts
+SET item_key = @item_key, guid = @guid, url = @url
+WHERE posts.id = (
+    SELECT legacy.id FROM posts AS legacy
+    WHERE legacy.feed_id = @feed_id
+      AND legacy.item_key IN ('link:' || @url::text, 'link:' || @raw_url::text)
+      AND legacy.guid IS NULL
+    ORDER BY legacy.seq
+    LIMIT 1
+)
+  AND NOT EXISTS (
+      SELECT 1 FROM posts AS keyed
+      WHERE keyed.feed_id = @feed_id
+        AND keyed.item_key = @item_key
+  );
@@ -0,0 +1,39 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, updated_at, user_id, name, scope, pattern, keywords, feed_id, author, category, language, action, tag)
+VALUES (@id, @created_at, @updated_at, @user_id, @name, @scope, @pattern, @keywords, @feed_id, @author, @category, @language, @action, @tag)
+RETURNING *;
+
+-- name: ListRulesForUser :many
+SELECT sqlc.embed(rules), feeds.url AS feed_url
+FROM rules
+LEFT JOIN feeds ON feeds.id = rules.feed_id
+WHERE rules.user_id = @user_id
+ORDER BY rules.name;
+
+-- name: GetRuleForUser :one
+SELECT sqlc.embed(rules), feeds.url AS feed_url
+FROM rules
+LEFT JOIN feeds ON feeds.id = rules.feed_id
+WHERE rules.user_id = @user_id
+  AND rules.name = @name;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE user_id = @user_id
+  AND name = @name;
+
+-- name: GetRulesForFeed :many
+-- The rules of every user who follows a feed, oldest first, with the
+-- category each follows it in.
+SELECT sqlc.embed(rules), users.name AS user_name, feed_follows.category AS follow_category
+FROM rules
+JOIN feed_follows ON feed_follows.user_id = rules.user_id
+    AND feed_follows.feed_id = @feed_id
+JOIN users ON users.id = rules.user_id
+WHERE rules.feed_id IS NULL OR rules.feed_id = @feed_id
+ORDER BY rules.user_id, rules.created_at;
+
+-- name: MoveFeedRules :execrows
+UPDATE rules
+SET feed_id = @to_feed_id::uuid, updated_at = @updated_at
+WHERE feed_id = @from_feed_id::uuid;
@@ -0,0 +1,58 @@
+-- name: TagPosts :execrows
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT @user_id::uuid, posts.id, tags.tag, @created_at::timestamptz
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    AND feed_follows.user_id = @user_id
+CROSS JOIN unnest(@tags::text[]) AS tags(tag)
+WHERE posts.seq BETWEEN @from_seq AND @to_seq
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
+
+-- name: UntagPosts :execrows
+DELETE FROM post_tags
+WHERE post_tags.user_id = @user_id
+  AND post_tags.tag = ANY(@tags::text[])
+  AND post_tags.post_id IN (
+      SELECT posts.id FROM posts
+      WHERE posts.seq BETWEEN @from_seq AND @to_seq
+  );
+
+-- name: TagFeed :execrows
+INSERT INTO feed_tags (user_id, feed_id, tag, created_at)
+SELECT @user_id::uuid, @feed_id::uuid, tags.tag, @created_at::timestamptz
+FROM unnest(@tags::text[]) AS tags(tag)
+ON CONFLICT (user_id, feed_id, tag) DO NOTHING;
+
+-- name: UntagFeed :execrows
+DELETE FROM feed_tags
+WHERE feed_tags.user_id = @user_id
+  AND feed_tags.feed_id = @feed_id
+  AND feed_tags.tag = ANY(@tags::text[]);
+
+-- name: ListTagsForUser :many
+SELECT post_tags.tag FROM post_tags
+WHERE post_tags.user_id = @user_id
+UNION
+SELECT feed_tags.tag FROM feed_tags
+WHERE feed_tags.user_id = @user_id
+ORDER BY 1;
+
+-- name: MoveFeedTags :execrows
+WITH moved AS (
+    DELETE FROM feed_tags
+    WHERE feed_id = @from_feed_id
+    RETURNING user_id, tag, created_at
+)
+INSERT INTO feed_tags (user_id, feed_id, tag, created_at)
+SELECT user_id, @to_feed_id::uuid, tag, created_at FROM moved
+ON CONFLICT (user_id, feed_id, tag) DO NOTHING;
+
+-- name: MovePostTags :execrows
+WITH moved AS (
+    DELETE FROM post_tags
+    WHERE post_id = @from_post_id
+    RETURNING user_id, tag, created_at
+)
+INSERT INTO post_tags (user_id, post_id, tag, created_at)
+SELECT user_id, @to_post_id::uuid, tag, created_at FROM moved
+ON CONFLICT (user_id, post_id, tag) DO NOTHING;
@@ -0,0 +1,93 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, created_at, user_id, url, secret, feed_id, rule_id)
+VALUES (@id, @created_at, @user_id, @url, @secret, @feed_id, @rule_id)
+RETURNING *;
+
+-- name: ListWebhooksForUser :many
+SELECT
+    webhooks.id, webhooks.created_at, webhooks.url,
+    feeds.url AS feed_url,
+    rules.name AS rule_name,
+    (SELECT count(*) FROM webhook_deliveries
+     WHERE webhook_deliveries.webhook_id = webhooks.id
+       AND webhook_deliveries.delivered_at IS NOT NULL) AS delivered,
+    (SELECT count(*) FROM webhook_deliveries
+     WHERE webhook_deliveries.webhook_id = webhooks.id
+       AND webhook_deliveries.next_attempt_at IS NOT NULL) AS pending,
+    (SELECT count(*) FROM webhook_deliveries
+     WHERE webhook_deliveries.webhook_id = webhooks.id
+       AND webhook_deliveries.delivered_at IS NULL
+       AND webhook_deliveries.next_attempt_at IS NULL) AS failed
+FROM webhooks
+LEFT JOIN feeds ON feeds.id = webhooks.feed_id
+LEFT JOIN rules ON rules.id = webhooks.rule_id
+WHERE webhooks.user_id = @user_id
+ORDER BY webhooks.created_at ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE user_id = @user_id
+  AND (id::text = @ref::text OR url = @ref::text);
+
+-- name: GetWebhooksForFeed :many
+-- The webhooks of every user who follows a feed that its new posts may go to.
+SELECT webhooks.id, webhooks.rule_id, users.name AS user_name, rules.name AS rule_name
+FROM webhooks
+JOIN feed_follows ON feed_follows.user_id = webhooks.user_id
+    AND feed_follows.feed_id = @feed_id
+JOIN users ON users.id = webhooks.user_id
+LEFT JOIN rules ON rules.id = webhooks.rule_id
+WHERE webhooks.feed_id IS NULL OR webhooks.feed_id = @feed_id;
+
+-- name: EnqueueWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, webhook_id, post_id, payload, next_attempt_at)
+VALUES (@id, @created_at, @webhook_id, @post_id, @payload, @created_at)
+ON CONFLICT (webhook_id, post_id) DO NOTHING;
+
+-- name: ClaimWebhookDeliveries :many
+-- Takes up to lim deliveries that are due and pushes their next attempt back
+-- to lease_until, so that another aggregator leaves them alone while they
+-- are being sent.
+UPDATE webhook_deliveries
+SET next_attempt_at = @lease_until::timestamptz
+FROM webhooks
+WHERE webhooks.id = webhook_deliveries.webhook_id
+  AND webhook_deliveries.id IN (
+      SELECT due.id FROM webhook_deliveries AS due
+      WHERE due.next_attempt_at <= @now::timestamptz
+      ORDER BY due.next_attempt_at
+      LIMIT @lim
+      FOR UPDATE SKIP LOCKED
+  )
+RETURNING webhook_deliveries.id, webhook_deliveries.payload, webhook_deliveries.attempts, webhooks.url, webhooks.secret;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET attempts = @attempts, next_attempt_at = @next_attempt_at, delivered_at = @delivered_at
+WHERE id = @id;
+
+-- name: RecordWebhookAttempt :exec
+INSERT INTO webhook_attempts (delivery_id, attempt, attempted_at, status_code, error)
+VALUES (@delivery_id, @attempt, @attempted_at, @status_code, @error);
+
+-- name: ListWebhookAttempts :many
+-- The delivery log of a user's webhooks, or of one of them, newest first.
+SELECT
+    webhook_attempts.delivery_id, webhook_attempts.attempt, webhook_attempts.attempted_at,
+    webhook_attempts.status_code, webhook_attempts.error,
+    webhooks.url,
+    posts.seq AS post_seq,
+    posts.title AS post_title
+FROM webhook_attempts
+JOIN webhook_deliveries ON webhook_deliveries.id = webhook_attempts.delivery_id
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+JOIN posts ON posts.id = webhook_deliveries.post_id
+WHERE webhooks.user_id = @user_id
+  AND (sqlc.narg(webhook_id)::uuid IS NULL OR webhooks.id = sqlc.narg(webhook_id))
+ORDER BY webhook_attempts.attempted_at DESC
+LIMIT @lim;
+
+-- name: MoveFeedWebhooks :execrows
+UPDATE webhooks
+SET feed_id = @to_feed_id::uuid
+WHERE feed_id = @from_feed_id::uuid;
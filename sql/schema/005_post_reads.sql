@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Short, stable numeric handle so posts can be referenced as `gator read 42`
+-- or `gator read 40-45` instead of by UUID.
+ALTER TABLE posts ADD COLUMN seq BIGSERIAL UNIQUE;
+
+CREATE TABLE post_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+
+DROP TABLE post_reads;
+ALTER TABLE posts DROP COLUMN seq;
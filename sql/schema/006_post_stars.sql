@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Stars reference posts with ON DELETE RESTRICT so that a starred post can
+-- never be removed by retention or cleanup jobs; unstar it first.
+CREATE TABLE post_stars (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE RESTRICT,
+    starred_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+
+DROP TABLE post_stars;
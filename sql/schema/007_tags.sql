@@ -0,0 +1,26 @@
+-- +goose Up
+
+CREATE TABLE post_tags (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, post_id, tag)
+);
+
+-- Tags on a feed apply to every post in that feed when filtering.
+CREATE TABLE feed_tags (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, feed_id, tag)
+);
+
+CREATE INDEX post_tags_user_tag_idx ON post_tags (user_id, tag);
+CREATE INDEX feed_tags_user_tag_idx ON feed_tags (user_id, tag);
+
+-- +goose Down
+
+DROP TABLE feed_tags;
+DROP TABLE post_tags;
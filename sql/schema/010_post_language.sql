@@ -0,0 +1,69 @@
+-- +goose Up
+
+ALTER TABLE posts ADD COLUMN language TEXT;
+
+-- Maps a post's ISO 639-1 language code to the matching text search
+-- configuration, falling back to 'simple' for languages Postgres has no
+-- stemmer for (e.g. Japanese).
+-- +goose StatementBegin
+CREATE FUNCTION gator_ts_config(lang TEXT) RETURNS regconfig
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT CASE lang
+        WHEN 'en' THEN 'pg_catalog.english'::regconfig
+        WHEN 'de' THEN 'pg_catalog.german'::regconfig
+        WHEN 'fr' THEN 'pg_catalog.french'::regconfig
+        WHEN 'es' THEN 'pg_catalog.spanish'::regconfig
+        WHEN 'nl' THEN 'pg_catalog.dutch'::regconfig
+        WHEN 'it' THEN 'pg_catalog.italian'::regconfig
+        WHEN 'pt' THEN 'pg_catalog.portuguese'::regconfig
+        WHEN 'ru' THEN 'pg_catalog.russian'::regconfig
+        WHEN 'sv' THEN 'pg_catalog.swedish'::regconfig
+        ELSE 'pg_catalog.simple'::regconfig
+    END
+$$;
+-- +goose StatementEnd
+
+-- Builds the search query for a language, or, when no language is given, the
+-- union of the query parsed under every configuration above so that posts
+-- indexed in any language can match.
+-- +goose StatementBegin
+CREATE FUNCTION gator_tsquery(lang TEXT, query TEXT) RETURNS tsquery
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN lang IS NOT NULL THEN websearch_to_tsquery(gator_ts_config(lang), query)
+        ELSE websearch_to_tsquery('pg_catalog.simple', query)
+            || websearch_to_tsquery('pg_catalog.english', query)
+            || websearch_to_tsquery('pg_catalog.german', query)
+            || websearch_to_tsquery('pg_catalog.french', query)
+            || websearch_to_tsquery('pg_catalog.spanish', query)
+            || websearch_to_tsquery('pg_catalog.dutch', query)
+            || websearch_to_tsquery('pg_catalog.italian', query)
+            || websearch_to_tsquery('pg_catalog.portuguese', query)
+            || websearch_to_tsquery('pg_catalog.russian', query)
+            || websearch_to_tsquery('pg_catalog.swedish', query)
+    END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(gator_ts_config(language), coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(gator_ts_config(language), coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+CREATE INDEX posts_language_idx ON posts (language);
+
+-- +goose Down
+
+DROP INDEX posts_language_idx;
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+DROP FUNCTION gator_tsquery(TEXT, TEXT);
+DROP FUNCTION gator_ts_config(TEXT);
+ALTER TABLE posts DROP COLUMN language;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Only a SHA-256 hash of each key is stored; the plaintext is shown once.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key_hash TEXT NOT NULL UNIQUE,
+    label TEXT NOT NULL
+);
+
+-- +goose Down
+
+DROP TABLE api_keys;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+ALTER TABLE api_keys
+    ADD COLUMN scope TEXT NOT NULL DEFAULT 'read-write'
+        CHECK (scope IN ('read', 'read-write')),
+    ADD COLUMN last_used_at TIMESTAMPTZ,
+    ADD CONSTRAINT api_keys_user_id_label_key UNIQUE (user_id, label);
+
+-- +goose Down
+
+ALTER TABLE api_keys
+    DROP CONSTRAINT api_keys_user_id_label_key,
+    DROP COLUMN last_used_at,
+    DROP COLUMN scope;
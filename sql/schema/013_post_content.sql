@@ -0,0 +1,40 @@
+-- +goose Up
+
+ALTER TABLE posts ADD COLUMN content TEXT;
+ALTER TABLE posts ADD COLUMN author TEXT;
+ALTER TABLE posts ADD COLUMN guid TEXT;
+ALTER TABLE posts ADD COLUMN comments_url TEXT;
+
+-- Returns whichever of a post's summary and full content says more, which
+-- is what gets read and searched.
+-- +goose StatementBegin
+CREATE FUNCTION gator_post_body(summary TEXT, content TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN length(coalesce(content, '')) > length(coalesce(summary, '')) THEN content
+        ELSE summary
+    END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(gator_ts_config(language), coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(gator_ts_config(language), coalesce(gator_post_body(description, content), '')), 'B')
+) STORED;
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+-- +goose Down
+
+ALTER TABLE posts DROP COLUMN search_vector;
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(gator_ts_config(language), coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(gator_ts_config(language), coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+DROP FUNCTION gator_post_body(TEXT, TEXT);
+ALTER TABLE posts DROP COLUMN comments_url;
+ALTER TABLE posts DROP COLUMN guid;
+ALTER TABLE posts DROP COLUMN author;
+ALTER TABLE posts DROP COLUMN content;
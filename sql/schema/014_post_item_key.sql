@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Identifies an item within its feed: its GUID or Atom id when it has one,
+-- otherwise its link, otherwise a hash of its text. Posts saved before this
+-- migration are keyed the same way from what was stored.
+ALTER TABLE posts ADD COLUMN item_key TEXT;
+UPDATE posts SET item_key = CASE
+    WHEN guid IS NOT NULL THEN 'guid:' || guid
+    ELSE 'link:' || url
+END;
+ALTER TABLE posts ALTER COLUMN item_key SET NOT NULL;
+
+-- Links are no longer unique: two feeds may carry the same article.
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+ALTER TABLE posts ADD CONSTRAINT posts_feed_id_item_key_key UNIQUE (feed_id, item_key);
+CREATE INDEX posts_url_idx ON posts (url);
+
+-- +goose Down
+
+DROP INDEX posts_url_idx;
+ALTER TABLE posts DROP CONSTRAINT posts_feed_id_item_key_key;
+-- Links must be unique again, so only the oldest post for each is kept.
+-- Stars block deleting a post; move them to the post that is kept first.
+WITH kept AS (
+    SELECT DISTINCT ON (url) url, id FROM posts ORDER BY url, seq
+)
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT post_stars.user_id, kept.id, post_stars.starred_at
+FROM post_stars
+JOIN posts ON posts.id = post_stars.post_id
+JOIN kept ON kept.url = posts.url AND kept.id <> posts.id
+ON CONFLICT (user_id, post_id) DO NOTHING;
+DELETE FROM post_stars USING posts a, posts b
+WHERE post_stars.post_id = a.id AND a.url = b.url AND a.seq > b.seq;
+DELETE FROM posts a USING posts b WHERE a.url = b.url AND a.seq > b.seq;
+ALTER TABLE posts ADD CONSTRAINT posts_url_key UNIQUE (url);
+ALTER TABLE posts DROP COLUMN item_key;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- Near-duplicate posts from different feeds (the same story syndicated by
+-- several aggregators) share a story_id. The first post of a story is its
+-- primary and uses its own id. simhash fingerprints the post's title and
+-- text; it is NULL when there was too little text to compare.
+ALTER TABLE posts ADD COLUMN simhash BIGINT;
+ALTER TABLE posts ADD COLUMN story_id UUID;
+UPDATE posts SET story_id = id;
+ALTER TABLE posts ALTER COLUMN story_id SET NOT NULL;
+
+CREATE INDEX posts_story_id_idx ON posts (story_id);
+CREATE INDEX posts_published_at_idx ON posts (published_at) WHERE simhash IS NOT NULL;
+
+-- +goose Down
+
+DROP INDEX posts_published_at_idx;
+DROP INDEX posts_story_id_idx;
+ALTER TABLE posts DROP COLUMN story_id;
+ALTER TABLE posts DROP COLUMN simhash;
@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- A rule acts on each new post, in a feed its owner follows, that matches
+-- every condition set on it. pattern and keywords are looked for in the part
+-- of the post named by scope. tag is only set for the tag action.
+CREATE TABLE rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    scope TEXT NOT NULL DEFAULT 'any' CHECK (scope IN ('any', 'title', 'content')),
+    pattern TEXT,
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    author TEXT,
+    category TEXT,
+    language TEXT,
+    action TEXT NOT NULL CHECK (action IN ('read', 'star', 'tag', 'hide', 'notify')),
+    tag TEXT,
+    UNIQUE (user_id, name),
+    CHECK ((action = 'tag') = (tag IS NOT NULL))
+);
+
+-- Hidden posts are left out of a user's timeline altogether, read or not.
+CREATE TABLE post_hides (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+
+DROP TABLE post_hides;
+DROP TABLE rules;
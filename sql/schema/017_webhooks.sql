@@ -0,0 +1,49 @@
+-- +goose Up
+
+-- A webhook receives each new post from its owner's followed feeds, or only
+-- those from feed_id, or only those rule_id matches. The secret signs every
+-- payload and so is stored as given.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES rules(id) ON DELETE CASCADE
+);
+
+-- The outbox: one row per post to send to a webhook. The payload is stored
+-- so retries send the same bytes. next_attempt_at is cleared once the post
+-- is delivered or the delivery is given up on.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (webhook_id, post_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+    WHERE next_attempt_at IS NOT NULL;
+
+-- The delivery log: every attempt and how it went. status_code is NULL
+-- when no response came back.
+CREATE TABLE webhook_attempts (
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    PRIMARY KEY (delivery_id, attempt)
+);
+
+-- +goose Down
+
+DROP TABLE webhook_attempts;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
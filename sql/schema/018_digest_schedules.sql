@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- A user's email digest, sent by the aggregator. schedule is a normalized
+-- spec such as "daily at 07:00" or "weekly on monday at 07:00", read in the
+-- IANA timezone given.
+CREATE TABLE digest_schedules (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    email TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    last_sent_at TIMESTAMPTZ
+);
+
+-- +goose Down
+
+DROP TABLE digest_schedules;
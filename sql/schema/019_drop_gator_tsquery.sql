@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- Searches now parse the query under each post's own configuration. The
+-- union this built across every configuration let a post match through one
+-- language while an exclusion only applied in another.
+DROP FUNCTION gator_tsquery(TEXT, TEXT);
+
+-- +goose Down
+
+-- +goose StatementBegin
+CREATE FUNCTION gator_tsquery(lang TEXT, query TEXT) RETURNS tsquery
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT CASE
+        WHEN lang IS NOT NULL THEN websearch_to_tsquery(gator_ts_config(lang), query)
+        ELSE websearch_to_tsquery('pg_catalog.simple', query)
+            || websearch_to_tsquery('pg_catalog.english', query)
+            || websearch_to_tsquery('pg_catalog.german', query)
+            || websearch_to_tsquery('pg_catalog.french', query)
+            || websearch_to_tsquery('pg_catalog.spanish', query)
+            || websearch_to_tsquery('pg_catalog.dutch', query)
+            || websearch_to_tsquery('pg_catalog.italian', query)
+            || websearch_to_tsquery('pg_catalog.portuguese', query)
+            || websearch_to_tsquery('pg_catalog.russian', query)
+            || websearch_to_tsquery('pg_catalog.swedish', query)
+    END
+$$;
+-- +goose StatementEnd
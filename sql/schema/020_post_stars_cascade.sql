@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Stars go with their post. ON DELETE RESTRICT kept a feed with a starred
+-- post from being deleted at all, and made 'gator reset' fail. Nothing
+-- prunes old posts; if that changes, it should skip starred ones itself.
+ALTER TABLE post_stars DROP CONSTRAINT post_stars_post_id_fkey;
+ALTER TABLE post_stars ADD CONSTRAINT post_stars_post_id_fkey
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE;
+
+-- +goose Down
+
+ALTER TABLE post_stars DROP CONSTRAINT post_stars_post_id_fkey;
+ALTER TABLE post_stars ADD CONSTRAINT post_stars_post_id_fkey
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE RESTRICT;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Titles, authors, bodies and feed names are stored without control
+-- characters, so nothing a feed sends can reach the terminal as an escape
+-- sequence. Clean what was saved before that.
+UPDATE posts SET
+    title = regexp_replace(title, '[\x01-\x08\x0b-\x1f\x7f-\x9f]', '', 'g'),
+    author = regexp_replace(author, '[\x01-\x08\x0b-\x1f\x7f-\x9f]', '', 'g'),
+    description = regexp_replace(description, '[\x01-\x08\x0b-\x1f\x7f-\x9f]', '', 'g'),
+    content = regexp_replace(content, '[\x01-\x08\x0b-\x1f\x7f-\x9f]', '', 'g')
+WHERE title ~ '[\x01-\x08\x0b-\x1f\x7f-\x9f]'
+   OR author ~ '[\x01-\x08\x0b-\x1f\x7f-\x9f]'
+   OR description ~ '[\x01-\x08\x0b-\x1f\x7f-\x9f]'
+   OR content ~ '[\x01-\x08\x0b-\x1f\x7f-\x9f]';
+
+UPDATE feeds SET name = regexp_replace(name, '[\x01-\x08\x0b-\x1f\x7f-\x9f]', '', 'g')
+WHERE name ~ '[\x01-\x08\x0b-\x1f\x7f-\x9f]';
+
+-- +goose Down
+
+-- The characters removed are not kept anywhere.
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Numpkens/gatorcli/internal/database"
+	"github.com/Numpkens/gatorcli/internal/render"
+	"github.com/Numpkens/gatorcli/internal/term"
+)
+
+// tuiPostLimit caps how many posts a source loads at once.
+const tuiPostLimit = 500
+
+const tuiHelp = "j/k move  enter/l open  h back  tab pane  m read  s star  o browser  r refresh  a all/unread  R reload  q quit"
+
+type tuiPane int
+
+const (
+	paneSources tuiPane = iota
+	panePosts
+	paneArticle
+)
+
+// tuiSource is an entry in the sources pane: every followed feed, starred
+// posts, a category or a single feed.
+type tuiSource struct {
+	Label    string
+	Indent   bool
+	Unread   int64
+	Starred  bool
+	Category string
+	FeedID   uuid.NullUUID
+	FeedURL  string
+}
+
+type tuiModel struct {
+	s    *state
+	user database.User
+
+	sources      []tuiSource
+	feedCategory map[uuid.UUID]string
+	posts        []database.GetPostsForUserRow
+
+	pane       tuiPane
+	source     int
+	sourceTop  int
+	post       int
+	postTop    int
+	articleTop int
+	showRead   bool
+
+	status  string
+	pending func(ctx context.Context)
+	quit    bool
+}
+
+func handlerTUI(s *state, cmd command, user database.User) error {
+	t, err := term.Open()
+	if err != nil {
+		return fmt.Errorf("failed to start the terminal UI: %w", err)
+	}
+	defer t.Close()
+
+	// Errors are shown in the status bar; log output would scribble over
+	// the screen.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := context.Background()
+	m := &tuiModel{s: s, user: user, status: tuiHelp}
+	if err := m.reload(ctx); err != nil {
+		m.status = err.Error()
+	}
+
+	for !m.quit {
+		w, h := t.Size()
+		c := term.NewCanvas(w, h)
+		m.draw(c)
+		if err := t.Draw(c); err != nil {
+			return err
+		}
+
+		if m.pending != nil {
+			pending := m.pending
+			m.pending = nil
+			pending(ctx)
+			continue
+		}
+
+		key, ok := <-t.Events()
+		if !ok {
+			return nil
+		}
+		m.handleKey(ctx, key, h)
+	}
+	return nil
+}
+
+// reload refreshes the sources and then the posts of the selected source.
+func (m *tuiModel) reload(ctx context.Context) error {
+	if err := m.loadSources(ctx); err != nil {
+		return err
+	}
+	return m.loadPosts(ctx)
+}
+
+func (m *tuiModel) loadSources(ctx context.Context) error {
+	follows, err := m.s.DB.GetFeedFollowsForUser(ctx, m.user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feed follows: %w", err)
+	}
+
+	var selected tuiSource
+	if m.source < len(m.sources) {
+		selected = m.sources[m.source]
+	}
+
+	all := tuiSource{Label: "All feeds"}
+	sources := []tuiSource{all, {Label: "Starred", Starred: true, Unread: -1}}
+	m.feedCategory = make(map[uuid.UUID]string, len(follows))
+	category := -1
+	for _, follow := range follows {
+		sources[0].Unread += follow.UnreadCount
+		m.feedCategory[follow.FeedID] = follow.Category.String
+		if follow.Category.Valid && (category < 0 || sources[category].Category != follow.Category.String) {
+			sources = append(sources, tuiSource{Label: follow.Category.String, Category: follow.Category.String})
+			category = len(sources) - 1
+		}
+		if follow.Category.Valid {
+			sources[category].Unread += follow.UnreadCount
+		}
+		sources = append(sources, tuiSource{
+			Label:   follow.FeedName,
+			Indent:  follow.Category.Valid,
+			Unread:  follow.UnreadCount,
+			FeedID:  uuid.NullUUID{UUID: follow.FeedID, Valid: true},
+			FeedURL: follow.FeedUrl,
+		})
+	}
+
+	m.sources = sources
+	m.source = 0
+	for i, src := range sources {
+		if src.Label == selected.Label && src.FeedID == selected.FeedID && src.Starred == selected.Starred {
+			m.source = i
+			break
+		}
+	}
+	return nil
+}
+
+// loadPosts loads the selected source's posts through the same query as
+// browse.
+func (m *tuiModel) loadPosts(ctx context.Context) error {
+	src := m.sources[m.source]
+	posts, err := m.s.DB.GetPostsForUser(ctx, database.GetPostsForUserParams{
+		UserID:      m.user.ID,
+		IncludeRead: m.showRead || src.Starred,
+		Category:    categoryParam(src.Category),
+		FeedID:      src.FeedID,
+		StarredOnly: src.Starred,
+		Lim:         tuiPostLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch posts: %w", err)
+	}
+	m.posts = posts
+	m.post, m.postTop, m.articleTop = 0, 0, 0
+	if m.pane == paneArticle && len(posts) == 0 {
+		m.pane = panePosts
+	}
+	return nil
+}
+
+func (m *tuiModel) handleKey(ctx context.Context, key term.Key, height int) {
+	page := max(height/2, 1)
+	m.status = ""
+
+	switch key.Code {
+	case term.KeyCtrlC:
+		m.quit = true
+		return
+	case term.KeyTab:
+		m.forward(ctx, false)
+		return
+	case term.KeyBacktab, term.KeyLeft, term.KeyEsc, term.KeyBackspace:
+		m.back()
+		return
+	case term.KeyRight, term.KeyEnter:
+		m.forward(ctx, true)
+		return
+	case term.KeyDown:
+		m.move(ctx, 1)
+	case term.KeyUp:
+		m.move(ctx, -1)
+	case term.KeyPageDown, term.KeyCtrlD:
+		m.move(ctx, page)
+	case term.KeyPageUp, term.KeyCtrlU:
+		m.move(ctx, -page)
+	case term.KeyHome:
+		m.move(ctx, -1<<30)
+	case term.KeyEnd:
+		m.move(ctx, 1<<30)
+	case term.KeyRune:
+		m.handleRune(ctx, key.Rune, page)
+	}
+}
+
+func (m *tuiModel) handleRune(ctx context.Context, r rune, page int) {
+	switch r {
+	case 'q':
+		if m.pane == paneArticle {
+			m.back()
+			return
+		}
+		m.quit = true
+	case 'j':
+		m.move(ctx, 1)
+	case 'k':
+		m.move(ctx, -1)
+	case ' ':
+		m.move(ctx, page)
+	case 'g':
+		m.move(ctx, -1<<30)
+	case 'G':
+		m.move(ctx, 1<<30)
+	case 'l':
+		m.forward(ctx, true)
+	case 'h':
+		m.back()
+	case 'n', 'p':
+		// Next or previous post without leaving the article.
+		if m.pane == paneArticle {
+			delta := 1
+			if r == 'p' {
+				delta = -1
+			}
+			if next := m.post + delta; next >= 0 && next < len(m.posts) {
+				m.post, m.articleTop = next, 0
+				m.markRead(ctx, true)
+			}
+		}
+	case 'm':
+		if post, ok := m.selectedPost(); ok {
+			m.markRead(ctx, !post.IsRead)
+		}
+	case 's':
+		m.toggleStar(ctx)
+	case 'o':
+		if post, ok := m.selectedPost(); ok {
+			if err := openBrowser(post.Url); err != nil {
+				m.status = err.Error()
+			} else {
+				m.status = "Opened " + post.Url
+			}
+		}
+	case 'r':
+		m.refresh()
+	case 'a':
+		m.showRead = !m.showRead
+		if err := m.loadPosts(ctx); err != nil {
+			m.status = err.Error()
+		}
+	case 'R':
+		if err := m.reload(ctx); err != nil {
+			m.status = err.Error()
+		}
+	case '?':
+		m.status = tuiHelp
+	}
+}
+
+// forward moves focus right. open also opens the selection: a source's
+// posts, or a post's article.
+func (m *tuiModel) forward(ctx context.Context, open bool) {
+	switch m.pane {
+	case paneSources:
+		m.pane = panePosts
+	case panePosts:
+		if !open && len(m.posts) == 0 {
+			m.pane = paneSources
+			return
+		}
+		if _, ok := m.selectedPost(); ok {
+			m.pane, m.articleTop = paneArticle, 0
+			m.markRead(ctx, true)
+		}
+	case paneArticle:
+		if !open {
+			m.pane = paneSources
+		}
+	}
+}
+
+func (m *tuiModel) back() {
+	if m.pane > paneSources {
+		m.pane--
+	}
+}
+
+// move shifts the selection of the focused pane, or scrolls the article.
+func (m *tuiModel) move(ctx context.Context, delta int) {
+	switch m.pane {
+	case paneSources:
+		next := clamp(m.source+delta, 0, len(m.sources)-1)
+		if next != m.source {
+			m.source = next
+			if err := m.loadPosts(ctx); err != nil {
+				m.status = err.Error()
+			}
+		}
+	case panePosts:
+		m.post = clamp(m.post+delta, 0, len(m.posts)-1)
+	case paneArticle:
+		m.articleTop = max(m.articleTop+delta, 0)
+	}
+}
+
+func (m *tuiModel) selectedPost() (database.GetPostsForUserRow, bool) {
+	if m.pane == paneSources || m.post >= len(m.posts) {
+		return database.GetPostsForUserRow{}, false
+	}
+	return m.posts[m.post], true
+}
+
+func (m *tuiModel) markRead(ctx context.Context, read bool) {
+	post, ok := m.selectedPost()
+	if !ok || post.IsRead == read {
+		return
+	}
+
+	var err error
+	if read {
+		_, err = m.s.DB.MarkPostsRead(ctx, database.MarkPostsReadParams{
+			UserID:  m.user.ID,
+			ReadAt:  time.Now().UTC(),
+			FromSeq: post.Seq,
+			ToSeq:   post.Seq,
+		})
+	} else {
+		_, err = m.s.DB.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{
+			UserID:  m.user.ID,
+			FromSeq: post.Seq,
+			ToSeq:   post.Seq,
+		})
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("failed to mark post %d: %v", post.Seq, err)
+		return
+	}
+
+	m.posts[m.post].IsRead = read
+	delta := int64(1)
+	if read {
+		delta = -1
+	}
+	category := m.feedCategory[post.FeedID]
+	for i, src := range m.sources {
+		switch {
+		case src.Starred:
+		case src.FeedID.Valid:
+			if src.FeedID.UUID == post.FeedID {
+				m.sources[i].Unread += delta
+			}
+		case src.Category == "" || src.Category == category:
+			m.sources[i].Unread += delta
+		}
+	}
+}
+
+func (m *tuiModel) toggleStar(ctx context.Context) {
+	post, ok := m.selectedPost()
+	if !ok {
+		return
+	}
+
+	var err error
+	if post.IsStarred {
+		_, err = m.s.DB.UnstarPosts(ctx, database.UnstarPostsParams{
+			UserID:  m.user.ID,
+			FromSeq: post.Seq,
+			ToSeq:   post.Seq,
+		})
+	} else {
+		_, err = m.s.DB.StarPosts(ctx, database.StarPostsParams{
+			UserID:    m.user.ID,
+			StarredAt: time.Now().UTC(),
+			FromSeq:   post.Seq,
+			ToSeq:     post.Seq,
+		})
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("failed to star post %d: %v", post.Seq, err)
+		return
+	}
+	m.posts[m.post].IsStarred = !post.IsStarred
+}
+
+// refresh fetches the selected feed, or the selected post's feed, after the
+// screen shows that it is busy.
+func (m *tuiModel) refresh() {
+	feedURL := m.sources[m.source].FeedURL
+	if post, ok := m.selectedPost(); ok {
+		feedURL = post.FeedUrl
+	}
+	if feedURL == "" {
+		m.status = "Select a feed or a post to refresh its feed."
+		return
+	}
+
+	m.status = "Refreshing " + feedURL + "…"
+	m.pending = func(ctx context.Context) {
+		dbFeed, err := getFeedByURL(ctx, m.s.DB, m.s.URLs, feedURL)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		saved, err := refreshFeed(ctx, m.s, dbFeed, io.Discard, nil)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		if err := m.reload(ctx); err != nil {
+			m.status = err.Error()
+			return
+		}
+		m.status = fmt.Sprintf("Fetched %d new posts from %s.", saved, dbFeed.Name)
+	}
+}
+
+func (m *tuiModel) draw(c *term.Canvas) {
+	w, h := c.Size()
+	if w < 40 || h < 8 {
+		c.Text(0, 0, w, term.Bold, "Terminal too small")
+		return
+	}
+
+	title := fmt.Sprintf(" gator — %s", m.user.Name)
+	if len(m.sources) > 0 {
+		title += " — " + m.sources[m.source].Label
+	}
+	if m.showRead {
+		title += " (all posts)"
+	}
+	c.Fill(0, 0, w, 1, term.Reverse)
+	c.Text(0, 0, w, term.Reverse|term.Bold, title)
+
+	c.Fill(0, h-1, w, 1, term.Reverse)
+	c.Text(1, h-1, w-2, term.Reverse, term.Truncate(m.status, w-2))
+
+	left := clamp(w/4, 20, 36)
+	bodyTop, bodyHeight := 1, h-2
+	for y := bodyTop; y < bodyTop+bodyHeight; y++ {
+		c.Text(left, y, 1, term.Dim, "│")
+	}
+	m.drawSources(c, 0, bodyTop, left, bodyHeight)
+
+	x, width := left+1, w-left-1
+	if m.pane == paneArticle {
+		m.drawArticle(c, x, bodyTop, width, bodyHeight)
+		return
+	}
+	listHeight := max(bodyHeight/3, 3)
+	m.drawPosts(c, x, bodyTop, width, listHeight)
+	c.Text(x, bodyTop+listHeight, width, term.Dim, strings.Repeat("─", width))
+	m.drawArticle(c, x, bodyTop+listHeight+1, width, bodyHeight-listHeight-1)
+}
+
+func (m *tuiModel) drawSources(c *term.Canvas, x, y, width, height int) {
+	m.sourceTop = scrollTo(m.source, m.sourceTop, height)
+	for i := m.sourceTop; i < len(m.sources) && i-m.sourceTop < height; i++ {
+		src := m.sources[i]
+		row := y + i - m.sourceTop
+
+		style := term.Style(0)
+		if src.Unread > 0 {
+			style = term.Bold
+		}
+		if i == m.source {
+			style |= selectedStyle(m.pane == paneSources)
+			c.Fill(x, row, width, 1, style)
+		}
+
+		count := ""
+		if src.Unread > 0 {
+			count = fmt.Sprint(src.Unread)
+		}
+		label := " " + src.Label
+		if src.Indent {
+			label = "   " + src.Label
+		}
+		labelWidth := width - term.StringWidth(count) - 2
+		c.Text(x, row, labelWidth, style, term.Truncate(label, labelWidth))
+		c.Text(x+width-term.StringWidth(count)-1, row, len(count), style, count)
+	}
+}
+
+func (m *tuiModel) drawPosts(c *term.Canvas, x, y, width, height int) {
+	if len(m.posts) == 0 {
+		msg := "No unread posts. Press a to include read posts."
+		if m.showRead || m.sources[m.source].Starred {
+			msg = "No posts."
+		}
+		c.Text(x+1, y, width-1, term.Dim, msg)
+		return
+	}
+
+	m.postTop = scrollTo(m.post, m.postTop, height)
+	for i := m.postTop; i < len(m.posts) && i-m.postTop < height; i++ {
+		post := m.posts[i]
+		row := y + i - m.postTop
+
+		style := term.Style(0)
+		if !post.IsRead {
+			style = term.Bold
+		}
+		if i == m.post {
+			style |= selectedStyle(m.pane == panePosts)
+			c.Fill(x, row, width, 1, style)
+		}
+
+		flags := "  "
+		if post.IsStarred {
+			flags = "* "
+		}
+		if !post.IsRead {
+			flags = flags[:1] + "•"
+		}
+		line := fmt.Sprintf(" %s %s  %s", flags, post.PublishedAt.Format("Jan 02"), post.Title)
+		feed := "  " + post.FeedName + " "
+		feedWidth := min(term.StringWidth(feed), width/3)
+		lineWidth := width - feedWidth
+		c.Text(x, row, lineWidth, style, term.Truncate(line, lineWidth))
+		c.Text(x+lineWidth, row, feedWidth, style|term.Dim, term.Truncate(feed, feedWidth))
+	}
+}
+
+func (m *tuiModel) drawArticle(c *term.Canvas, x, y, width, height int) {
+	post, ok := m.selectedPost()
+	if !ok || height <= 0 {
+		return
+	}
+	x, width = x+1, width-2
+
+	type line struct {
+		text  string
+		style term.Style
+	}
+	var lines []line
+	for _, l := range term.Wrap(post.Title, width) {
+		lines = append(lines, line{l, term.Bold})
+	}
+	meta := postMeta(post.FeedName, post.Author, post.PublishedAt)
+	if post.IsStarred {
+		meta += " | starred"
+	}
+	if len(post.AlsoIn) > 0 {
+		meta += " | also in: " + strings.Join(post.AlsoIn, ", ")
+	}
+	lines = append(lines, line{term.Truncate(meta, width), term.Dim}, line{term.Truncate(post.Url, width), term.Underline}, line{})
+	if body := render.Text(postBody(post.Description, post.Content), width); body != "" {
+		for _, l := range strings.Split(body, "\n") {
+			lines = append(lines, line{l, 0})
+		}
+	}
+
+	m.articleTop = min(m.articleTop, max(len(lines)-height, 0))
+	for i := m.articleTop; i < len(lines) && i-m.articleTop < height; i++ {
+		c.Text(x, y+i-m.articleTop, width, lines[i].style, lines[i].text)
+	}
+}
+
+func selectedStyle(focused bool) term.Style {
+	if focused {
+		return term.Reverse
+	}
+	return term.Underline
+}
+
+// scrollTo returns the first visible row so that selected is on screen.
+func scrollTo(selected, top, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+// openBrowser opens link with $BROWSER or the platform's default handler,
+// without waiting for it. Post links come from feeds, so anything but an
+// absolute http or https URL is refused rather than handed to a handler that
+// might run a file: or custom-scheme target.
+func openBrowser(link string) error {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("not opening '%s': only http and https links are opened", link)
+	}
+	link = u.String()
+
+	var cmd *exec.Cmd
+	switch {
+	case os.Getenv("BROWSER") != "":
+		cmd = exec.Command(os.Getenv("BROWSER"), link)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", link)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}